@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+	"github.com/spf13/cobra"
+)
+
+// newCacheCommand creates the cache subcommand tree sharing the root --cache-dir and --cache-ttl flags.
+func newCacheCommand(cfg *RootConfig) *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "inspect, prune, or clear the response cache",
+		Args:  cobra.NoArgs,
+	}
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "info",
+		Short: "list cached responses",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cache, err := cacheFor(cfg)
+			if err != nil {
+				return err
+			}
+			entries, err := cache.Entries()
+			if err != nil {
+				return err
+			}
+			fresh := 0
+			var size int64
+			for _, entry := range entries {
+				state := "stale"
+				if entry.Fresh {
+					state = "fresh"
+					fresh++
+				}
+				size += entry.Size
+				if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%d\t%s\n", state, entry.StoredAt.Format(time.RFC3339), entry.Size, entry.URL); err != nil {
+					return err
+				}
+			}
+			_, err = fmt.Fprintf(cmd.ErrOrStderr(), "summary entries=%d fresh=%d stale=%d bytes=%d\n", len(entries), fresh, len(entries)-fresh, size)
+			return err
+		},
+	})
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "prune",
+		Short: "remove responses older than --cache-ttl",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cache, err := cacheFor(cfg)
+			if err != nil {
+				return err
+			}
+			removed, err := cache.Prune()
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(cmd.ErrOrStderr(), "summary removed=%d\n", removed)
+			return err
+		},
+	})
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "remove all cached responses",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cache, err := cacheFor(cfg)
+			if err != nil {
+				return err
+			}
+			removed, err := cache.Clear()
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(cmd.ErrOrStderr(), "summary removed=%d\n", removed)
+			return err
+		},
+	})
+	return cacheCmd
+}
+
+// cacheFor validates the cache flags and opens the configured cache.
+func cacheFor(cfg *RootConfig) (*niconico.DiskCache, error) {
+	if cfg.CacheDir == "" {
+		return nil, errors.New("cache-dir is required")
+	}
+	if cfg.CacheTTL < 0 {
+		return nil, errors.New("cache-ttl must be at least 0")
+	}
+	return niconico.NewDiskCache(cfg.CacheDir, cfg.CacheTTL), nil
+}
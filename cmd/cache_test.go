@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunRootCmdCacheDirServesRepeatedRuns(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[{"essential":{"id":"sm1","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}}}]}}`)
+	}))
+	t.Cleanup(server.Close)
+	cfg := testFetchConfig(server.URL)
+	cfg.CacheDir = filepath.Join(t.TempDir(), "cache")
+
+	for _, comment := range []string{"0", "5"} {
+		out, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "--comment", comment, "nicovideo.jp/user/1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != "sm1\n" {
+			t.Fatalf("unexpected output: %q", out.String())
+		}
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expected the second run to be served from cache, got %d requests", requests.Load())
+	}
+
+	out, errOut, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "cache", "info")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], "fresh\t") || !strings.Contains(lines[0], server.URL+"/users/1/videos") {
+		t.Fatalf("unexpected cache info output: %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "summary entries=2 fresh=2 stale=0") {
+		t.Fatalf("unexpected cache info summary: %q", errOut.String())
+	}
+
+	_, errOut, err = executeTestRootCommand(t, cfg, newTestRootDeps(), "cache", "prune")
+	if err != nil || !strings.Contains(errOut.String(), "summary removed=0") {
+		t.Fatalf("expected prune to keep fresh entries, got %q (%v)", errOut.String(), err)
+	}
+	_, errOut, err = executeTestRootCommand(t, cfg, newTestRootDeps(), "cache", "prune", "--cache-ttl", "0s")
+	if err != nil || !strings.Contains(errOut.String(), "summary removed=2") {
+		t.Fatalf("expected prune with zero ttl to remove every entry, got %q (%v)", errOut.String(), err)
+	}
+	_, errOut, err = executeTestRootCommand(t, cfg, newTestRootDeps(), "cache", "clear")
+	if err != nil || !strings.Contains(errOut.String(), "summary removed=0") {
+		t.Fatalf("unexpected clear result: %q (%v)", errOut.String(), err)
+	}
+}
+
+func TestCacheCommandRequiresCacheDir(t *testing.T) {
+	_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), "cache", "clear")
+	if err == nil || err.Error() != "cache-dir is required" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
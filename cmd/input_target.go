@@ -1,10 +1,20 @@
 package cmd
 
-import "regexp"
+import (
+	"net/url"
+	"regexp"
+)
 
 const (
-	targetTypeUser   = "user"
-	targetTypeMylist = "mylist"
+	targetTypeUser    = "user"
+	targetTypeMylist  = "mylist"
+	targetTypeSeries  = "series"
+	targetTypeChannel = "channel"
+	targetTypeTag     = "tag"
+	targetTypeSearch  = "search"
+
+	targetTypeUserMylists = "user_mylists"
+	targetTypeUserSeries  = "user_series"
 )
 
 type inputTarget struct {
@@ -12,21 +22,101 @@ type inputTarget struct {
 	ID   string
 }
 
+// isExpansion reports whether the target lists other targets instead of videos.
+func (t inputTarget) isExpansion() bool {
+	return t.Type == targetTypeUserMylists || t.Type == targetTypeUserSeries
+}
+
 var (
 	userInputPattern   = regexp.MustCompile(`((http(s)?://)?(www\.)?)nicovideo\.jp/user/(?P<userID>\d{1,9})(/video)?`)
 	mylistInputPattern = regexp.MustCompile(`((http(s)?://)?(www\.)?)nicovideo\.jp/mylist/(?P<mylistID>\d{1,12})`) // mylist IDs are numeric
+	seriesInputPattern = regexp.MustCompile(`((http(s)?://)?(www\.)?)nicovideo\.jp/series/(?P<seriesID>\d{1,12})`)
+	// Channels are addressed by "ch<digits>" or a screen name; a bare "ch<digits>" must be the whole input.
+	channelInputPattern     = regexp.MustCompile(`((http(s)?://)?)ch\.nicovideo\.jp/(?P<channelID>[A-Za-z0-9][A-Za-z0-9_-]{0,63})`)
+	bareChannelInputPattern = regexp.MustCompile(`^(?P<channelID>ch\d{1,12})$`)
+	// Tags and keywords are path-escaped in URLs and unescaped into the target ID.
+	tagInputPattern    = regexp.MustCompile(`((http(s)?://)?(www\.)?)nicovideo\.jp/tag/(?P<tag>[^/?#\s]+)`)
+	searchInputPattern = regexp.MustCompile(`((http(s)?://)?(www\.)?)nicovideo\.jp/search/(?P<keyword>[^/?#\s]+)`)
+	// Patterns nested under a user path take precedence over userInputPattern, which would otherwise match their prefix.
+	userMylistInputPattern = regexp.MustCompile(`((http(s)?://)?(www\.)?)nicovideo\.jp/user/\d{1,9}/mylist/(?P<mylistID>\d{1,12})`)
+	userSeriesInputPattern = regexp.MustCompile(`((http(s)?://)?(www\.)?)nicovideo\.jp/user/\d{1,9}/series/(?P<seriesID>\d{1,12})`)
+	userListsInputPattern  = regexp.MustCompile(`((http(s)?://)?(www\.)?)nicovideo\.jp/user/(?P<userID>\d{1,9})/(?P<kind>mylist|series)/?([?#]|$)`)
 )
 
+// parseInputTarget extracts the first supported target from input, checking user-nested paths before plain user URLs.
 func parseInputTarget(input string) (inputTarget, bool) {
+	if match := userListsInputPattern.FindStringSubmatch(input); len(match) > 0 {
+		targetType := targetTypeUserMylists
+		if submatchByName(match, userListsInputPattern, "kind") == "series" {
+			targetType = targetTypeUserSeries
+		}
+		return inputTarget{Type: targetType, ID: submatchByName(match, userListsInputPattern, "userID")}, true
+	}
+	if match := userMylistInputPattern.FindStringSubmatch(input); len(match) > 0 {
+		return inputTarget{Type: targetTypeMylist, ID: submatchByName(match, userMylistInputPattern, "mylistID")}, true
+	}
+	if match := userSeriesInputPattern.FindStringSubmatch(input); len(match) > 0 {
+		return inputTarget{Type: targetTypeSeries, ID: submatchByName(match, userSeriesInputPattern, "seriesID")}, true
+	}
 	if match := userInputPattern.FindStringSubmatch(input); len(match) > 0 {
 		return inputTarget{Type: targetTypeUser, ID: submatchByName(match, userInputPattern, "userID")}, true
 	}
 	if match := mylistInputPattern.FindStringSubmatch(input); len(match) > 0 {
 		return inputTarget{Type: targetTypeMylist, ID: submatchByName(match, mylistInputPattern, "mylistID")}, true
 	}
+	if match := seriesInputPattern.FindStringSubmatch(input); len(match) > 0 {
+		return inputTarget{Type: targetTypeSeries, ID: submatchByName(match, seriesInputPattern, "seriesID")}, true
+	}
+	if match := channelInputPattern.FindStringSubmatch(input); len(match) > 0 {
+		return inputTarget{Type: targetTypeChannel, ID: submatchByName(match, channelInputPattern, "channelID")}, true
+	}
+	if match := bareChannelInputPattern.FindStringSubmatch(input); len(match) > 0 {
+		return inputTarget{Type: targetTypeChannel, ID: submatchByName(match, bareChannelInputPattern, "channelID")}, true
+	}
+	if match := tagInputPattern.FindStringSubmatch(input); len(match) > 0 {
+		return inputTarget{Type: targetTypeTag, ID: unescapeTargetPath(submatchByName(match, tagInputPattern, "tag"))}, true
+	}
+	if match := searchInputPattern.FindStringSubmatch(input); len(match) > 0 {
+		return inputTarget{Type: targetTypeSearch, ID: unescapeTargetPath(submatchByName(match, searchInputPattern, "keyword"))}, true
+	}
 	return inputTarget{}, false
 }
 
+// targetInput returns the canonical input string for a video target.
+func targetInput(target inputTarget) string {
+	return "nicovideo.jp/" + target.Type + "/" + url.PathEscape(target.ID)
+}
+
+// targetPageURL returns the niconico web page that lists a target's videos.
+func targetPageURL(target inputTarget) string {
+	switch target.Type {
+	case targetTypeUser:
+		return "https://www.nicovideo.jp/user/" + target.ID + "/video"
+	case targetTypeUserMylists:
+		return "https://www.nicovideo.jp/user/" + target.ID + "/mylist"
+	case targetTypeUserSeries:
+		return "https://www.nicovideo.jp/user/" + target.ID + "/series"
+	case targetTypeChannel:
+		return "https://ch.nicovideo.jp/" + target.ID
+	default:
+		return "https://www." + targetInput(target)
+	}
+}
+
+// queryInput returns the input string for a --query keyword.
+func queryInput(keyword string) string {
+	return targetInput(inputTarget{Type: targetTypeSearch, ID: keyword})
+}
+
+// unescapeTargetPath decodes a path-escaped tag or keyword, keeping the raw text when it is not valid escaping.
+func unescapeTargetPath(value string) string {
+	unescaped, err := url.PathUnescape(value)
+	if err != nil {
+		return value
+	}
+	return unescaped
+}
+
 func submatchByName(match []string, re *regexp.Regexp, name string) string {
 	idx := re.SubexpIndex(name)
 	if idx < 0 || idx >= len(match) {
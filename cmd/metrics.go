@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+const (
+	metricsPrefix      = "go_nico_list_"
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+	metricsFileMode    = 0o644
+)
+
+var (
+	// latencyBuckets are the histogram bounds, in seconds, of page latency.
+	latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	// waitBuckets are the histogram bounds, in seconds, of rate limiter and Retry-After waits.
+	waitBuckets = []float64{0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+)
+
+// runMetrics collects request and target measurements in the Prometheus text format; it is the niconico.Observer of a run.
+type runMetrics struct {
+	mu            sync.Mutex
+	requests      map[string]uint64
+	retries       uint64
+	rateLimited   uint64
+	retryAfter    metricsHistogram
+	limiterWait   metricsHistogram
+	pageLatency   metricsHistogram
+	targetsOK     uint64
+	targetsFailed uint64
+	itemsEmitted  uint64
+}
+
+var _ niconico.Observer = (*runMetrics)(nil)
+
+// metricsHistogram is a cumulative histogram with fixed upper bounds.
+type metricsHistogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// newRunMetrics returns empty metrics.
+func newRunMetrics() *runMetrics {
+	return &runMetrics{
+		requests:    make(map[string]uint64),
+		retryAfter:  newMetricsHistogram(waitBuckets),
+		limiterWait: newMetricsHistogram(waitBuckets),
+		pageLatency: newMetricsHistogram(latencyBuckets),
+	}
+}
+
+func newMetricsHistogram(bounds []float64) metricsHistogram {
+	return metricsHistogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+// observe adds one value to every bucket whose bound it does not exceed.
+func (h *metricsHistogram) observe(value float64) {
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// ObserveRequest counts an API attempt by status code; "error" means no response was received.
+func (m *runMetrics) ObserveRequest(status int) {
+	code := "error"
+	if status != 0 {
+		code = strconv.Itoa(status)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[code]++
+	if status == http.StatusTooManyRequests {
+		m.rateLimited++
+	}
+}
+
+// ObserveRetry counts a retry and records the Retry-After delay it honors.
+func (m *runMetrics) ObserveRetry(retryAfter time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries++
+	if retryAfter > 0 {
+		m.retryAfter.observe(retryAfter.Seconds())
+	}
+}
+
+// ObserveLimiterWait records how long the rate limiter delayed an attempt.
+func (m *runMetrics) ObserveLimiterWait(wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limiterWait.observe(wait.Seconds())
+}
+
+// ObservePage records the latency of a page or list request.
+func (m *runMetrics) ObservePage(latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pageLatency.observe(latency.Seconds())
+}
+
+// observeTarget counts a finished target.
+func (m *runMetrics) observeTarget(ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ok {
+		m.targetsOK++
+	} else {
+		m.targetsFailed++
+	}
+}
+
+// observeItems counts videos written to the output.
+func (m *runMetrics) observeItems(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.itemsEmitted += uint64(count)
+}
+
+// writeText writes the metrics in the Prometheus text exposition format.
+func (m *runMetrics) writeText(w io.Writer) error {
+	var buf bytes.Buffer
+	m.mu.Lock()
+	writeMetricHeader(&buf, "http_requests_total", "counter", "API request attempts by HTTP status code (error when no response was received).")
+	for _, code := range slices.Sorted(maps.Keys(m.requests)) {
+		fmt.Fprintf(&buf, "%shttp_requests_total{code=%q} %d\n", metricsPrefix, code, m.requests[code])
+	}
+	writeCounter(&buf, "http_retries_total", "API request attempts that were retried.", m.retries)
+	writeCounter(&buf, "http_rate_limited_total", "API responses with HTTP 429 Too Many Requests.", m.rateLimited)
+	writeHistogram(&buf, "retry_after_wait_seconds", "Retry-After delays honored before retrying.", m.retryAfter)
+	writeHistogram(&buf, "rate_limiter_wait_seconds", "Time each attempt waited for the rate limiter.", m.limiterWait)
+	writeHistogram(&buf, "page_duration_seconds", "Latency of page and list requests, including retries and cache lookups.", m.pageLatency)
+	writeMetricHeader(&buf, "targets_total", "counter", "Fetched targets by result.")
+	fmt.Fprintf(&buf, "%stargets_total{result=\"ok\"} %d\n", metricsPrefix, m.targetsOK)
+	fmt.Fprintf(&buf, "%stargets_total{result=\"failed\"} %d\n", metricsPrefix, m.targetsFailed)
+	writeCounter(&buf, "items_emitted_total", "Videos written to the output.", m.itemsEmitted)
+	m.mu.Unlock()
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ServeHTTP serves the metrics for a Prometheus scrape.
+func (m *runMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", metricsContentType)
+	_ = m.writeText(w)
+}
+
+func writeMetricHeader(buf *bytes.Buffer, name, kind, help string) {
+	fmt.Fprintf(buf, "# HELP %s%s %s\n# TYPE %s%s %s\n", metricsPrefix, name, help, metricsPrefix, name, kind)
+}
+
+func writeCounter(buf *bytes.Buffer, name, help string, value uint64) {
+	writeMetricHeader(buf, name, "counter", help)
+	fmt.Fprintf(buf, "%s%s %d\n", metricsPrefix, name, value)
+}
+
+func writeHistogram(buf *bytes.Buffer, name, help string, h metricsHistogram) {
+	writeMetricHeader(buf, name, "histogram", help)
+	for i, bound := range h.bounds {
+		fmt.Fprintf(buf, "%s%s_bucket{le=%q} %d\n", metricsPrefix, name, formatMetricValue(bound), h.counts[i])
+	}
+	fmt.Fprintf(buf, "%s%s_bucket{le=\"+Inf\"} %d\n", metricsPrefix, name, h.count)
+	fmt.Fprintf(buf, "%s%s_sum %s\n", metricsPrefix, name, formatMetricValue(h.sum))
+	fmt.Fprintf(buf, "%s%s_count %d\n", metricsPrefix, name, h.count)
+}
+
+func formatMetricValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// writeMetricsFile replaces path with the metrics and the time of the write, for the node_exporter textfile collector; an empty path does nothing.
+func writeMetricsFile(path string, metrics *runMetrics, now time.Time) error {
+	if path == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := metrics.writeText(&buf); err != nil {
+		return err
+	}
+	writeMetricHeader(&buf, "last_run_timestamp_seconds", "gauge", "Unix time the metrics file was written.")
+	fmt.Fprintf(&buf, "%slast_run_timestamp_seconds %d\n", metricsPrefix, now.Unix())
+	if err := writeFileAtomicallyWithMode(path, buf.Bytes(), metricsFileMode); err != nil {
+		return fmt.Errorf("metrics-file %s: %w", path, err)
+	}
+	return nil
+}
+
+// startMetricsServer serves the metrics on addr/metrics in the background and returns the bound address and a shutdown func.
+func startMetricsServer(ctx context.Context, addr string, metrics *runMetrics) (net.Addr, func() error, error) {
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: serveReadHeaderTimeout}
+	go func() { _ = server.Serve(listener) }()
+	shutdown := func() error {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+	return listener.Addr(), shutdown, nil
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsTextFormat(t *testing.T) {
+	metrics := newRunMetrics()
+	metrics.ObserveRequest(http.StatusOK)
+	metrics.ObserveRequest(http.StatusTooManyRequests)
+	metrics.ObserveRequest(0)
+	metrics.ObserveRetry(3 * time.Second)
+	metrics.ObserveRetry(0)
+	metrics.ObserveLimiterWait(200 * time.Millisecond)
+	metrics.ObservePage(30 * time.Millisecond)
+	metrics.observeTarget(true)
+	metrics.observeTarget(false)
+	metrics.observeItems(7)
+	var buf strings.Builder
+	if err := metrics.writeText(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"# TYPE go_nico_list_http_requests_total counter\n",
+		`go_nico_list_http_requests_total{code="200"} 1` + "\n",
+		`go_nico_list_http_requests_total{code="429"} 1` + "\n",
+		`go_nico_list_http_requests_total{code="error"} 1` + "\n",
+		"go_nico_list_http_retries_total 2\n",
+		"go_nico_list_http_rate_limited_total 1\n",
+		`go_nico_list_retry_after_wait_seconds_bucket{le="2.5"} 0` + "\n",
+		`go_nico_list_retry_after_wait_seconds_bucket{le="5"} 1` + "\n",
+		"go_nico_list_retry_after_wait_seconds_count 1\n",
+		`go_nico_list_rate_limiter_wait_seconds_bucket{le="0.1"} 0` + "\n",
+		`go_nico_list_rate_limiter_wait_seconds_bucket{le="0.5"} 1` + "\n",
+		"go_nico_list_rate_limiter_wait_seconds_sum 0.2\n",
+		`go_nico_list_page_duration_seconds_bucket{le="0.05"} 1` + "\n",
+		`go_nico_list_page_duration_seconds_bucket{le="+Inf"} 1` + "\n",
+		`go_nico_list_targets_total{result="ok"} 1` + "\n",
+		`go_nico_list_targets_total{result="failed"} 1` + "\n",
+		"go_nico_list_items_emitted_total 7\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestRunWritesMetricsFile(t *testing.T) {
+	for _, format := range []string{"lines", "json"} {
+		server := newFormatTestServer(t)
+		path := filepath.Join(t.TempDir(), "go_nico_list.prom")
+		deps := newTestRootDeps()
+		deps.Now = func() time.Time { return time.Unix(1700000000, 0) }
+		_, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), deps,
+			"--format", format, "--comment", "0", "--metrics-file", path, "nicovideo.jp/user/1", "nicovideo.jp/user/abc")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: read metrics file: %v", format, err)
+		}
+		for _, want := range []string{
+			`go_nico_list_http_requests_total{code="200"} 2` + "\n",
+			`go_nico_list_targets_total{result="ok"} 1` + "\n",
+			"go_nico_list_items_emitted_total 2\n",
+			"go_nico_list_last_run_timestamp_seconds 1700000000\n",
+		} {
+			if !strings.Contains(string(data), want) {
+				t.Fatalf("%s: missing %q in:\n%s", format, want, data)
+			}
+		}
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != metricsFileMode {
+			t.Fatalf("%s: unexpected metrics file mode: %v, %v", format, info.Mode(), err)
+		}
+	}
+}
+
+// scrapeMetrics returns the body of GET url.
+func scrapeMetrics(t *testing.T, url string) string {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != metricsContentType {
+		t.Fatalf("unexpected scrape: %d %s %v", resp.StatusCode, resp.Header.Get("Content-Type"), err)
+	}
+	return string(body)
+}
+
+func TestWatchServesMetrics(t *testing.T) {
+	server, requests := newWatchTestServer(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stderr, stderrWriter := io.Pipe()
+	deps := newTestRootDeps()
+	deps.Stderr = stderrWriter
+	cmd, _, _ := newTestRootCommand(t, testFetchConfig(server.URL), deps)
+	cmd.SetContext(ctx)
+	cmd.SetArgs([]string{"watch", "--interval", "1h", "--rate-limit", "0", "--metrics-addr", "127.0.0.1:0", "nicovideo.jp/user/1"})
+	done := make(chan error, 1)
+	go func() { done <- cmd.Execute() }()
+	reader := bufio.NewReader(stderr)
+	line, err := reader.ReadString('\n')
+	addr, ok := strings.CutPrefix(strings.TrimSpace(line), "metrics listening on ")
+	if err != nil || !ok {
+		t.Fatalf("unexpected stderr: %q, %v", line, err)
+	}
+	// The summary line follows the first poll.
+	if line, err := reader.ReadString('\n'); err != nil || !strings.Contains(line, "poll=1") {
+		t.Fatalf("unexpected summary: %q, %v", line, err)
+	}
+	go func() { _, _ = io.Copy(io.Discard, stderr) }()
+	body := scrapeMetrics(t, "http://"+addr+"/metrics")
+	if requests.Load() != 1 || !strings.Contains(body, `go_nico_list_targets_total{result="ok"} 1`+"\n") {
+		t.Fatalf("unexpected metrics after %d requests:\n%s", requests.Load(), body)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop after cancel")
+	}
+}
+
+func TestServeExposesMetrics(t *testing.T) {
+	upstream := newFormatTestServer(t)
+	server := newTestAPIServer(t, upstream.URL, time.Minute, fixedNow)
+	for range 2 {
+		if status, _, body := getAPI(t, server, "/v1/users/1/videos?comment=0"); status != http.StatusOK {
+			t.Fatalf("unexpected response: %d %s", status, body)
+		}
+	}
+	body := scrapeMetrics(t, server.URL+"/metrics")
+	// The second request is a response cache hit, so only one target was fetched.
+	for _, want := range []string{
+		`go_nico_list_targets_total{result="ok"} 1` + "\n",
+		"go_nico_list_items_emitted_total 2\n",
+		"go_nico_list_page_duration_seconds_count 2\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+}
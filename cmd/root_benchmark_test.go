@@ -6,24 +6,26 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
 )
 
 func BenchmarkBuildJSONOutputLarge(b *testing.B) {
 	targets := make([]targetResult, 100)
-	outputIDs := make([]string, 0, 5000)
+	outputVideos := make([]niconico.Video, 0, 5000)
 	for i := range targets {
 		items := make([]string, 50)
 		for j := range items {
 			items[j] = fmt.Sprintf("sm%d", i*50+j)
+			outputVideos = append(outputVideos, niconico.Video{ID: items[j]})
 		}
 		targets[i] = targetResult{Type: targetTypeUser, ID: fmt.Sprintf("%d", i), Items: items}
-		outputIDs = append(outputIDs, items...)
 	}
 
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
-		payload := buildJSONOutput(100, 100, 0, nil, targets, nil, len(outputIDs), outputIDs)
-		if payload.OutputCount != len(outputIDs) {
+		payload := buildJSONOutput(100, 100, 0, nil, targets, nil, len(outputVideos), outputVideos)
+		if payload.OutputCount != len(outputVideos) {
 			b.Fatalf("unexpected output_count: %d", payload.OutputCount)
 		}
 	}
@@ -13,8 +13,30 @@ import (
 // RootConfig contains all root command flag values and runtime defaults.
 type RootConfig struct {
 	Comment           int
+	MinViews          int
+	MaxViews          int
+	MinLikes          int
+	MaxLikes          int
+	MinMylists        int
+	MaxMylists        int
+	MinComment        int
+	MaxComment        int
+	MinDuration       time.Duration
+	MaxDuration       time.Duration
+	FilterExpr        string
+	ExcludeChannel    bool
+	ExcludePaid       bool
+	ExcludeSensitive  bool
+	OwnerType         string
+	TitleMatch        string
+	TitleExclude      string
+	DescMatch         string
+	DescExclude       string
+	IgnoreCase        bool
+	NormalizeWidth    bool
 	DateAfter         string
 	DateBefore        string
+	Timezone          string
 	URL               bool
 	Concurrency       int
 	PageConcurrency   int
@@ -23,6 +45,30 @@ type RootConfig struct {
 	InputFilePath     string
 	ReadStdin         bool
 	LogFilePath       string
+	StateFilePath     string
+	SinceOutput       string
+	SaveOutput        string
+	MetricsFile       string
+	MetricsAddr       string
+	SeenFilePath      string
+	WatchInterval     time.Duration
+	WatchJitter       time.Duration
+	WatchPolls        int
+	EmitInitial       bool
+	Webhooks          []string
+	WebhookFormat     string
+	WebhookBatch      bool
+	WebhookRetries    int
+	WebhookRetryWait  time.Duration
+	ExecCommand       string
+	ExecConcurrency   int
+	ExecRetries       int
+	ExecRetryWait     time.Duration
+	ExecTimeout       time.Duration
+	ServeAddr         string
+	ResponseTTL       time.Duration
+	CacheDir          string
+	CacheTTL          time.Duration
 	ForceProgress     bool
 	NoProgress        bool
 	StrictInput       bool
@@ -30,9 +76,22 @@ type RootConfig struct {
 	DedupeOutput      bool
 	NoSortOutput      bool
 	JSONOutput        bool
+	SchemaVersion     int
+	PrintJSONSchema   bool
+	Format            string
+	Columns           []string
+	Template          string
+	TemplateFile      string
+	OutputHints       bool
+	FeedTitle         string
+	FeedDir           string
 	RateLimit         float64
 	MinInterval       time.Duration
+	SortKey           string
+	SortOrder         string
+	Queries           []string
 	BaseURL           string
+	SearchBaseURL     string
 	Version           string
 }
 
@@ -45,18 +104,34 @@ type RootDeps struct {
 	ProgressBarNew func(int64, io.Writer, bool) *progressbar.ProgressBar
 	OpenInputFile  func(string) (io.ReadCloser, error)
 	IsTerminal     func(io.Writer) bool
+	Now            func() time.Time
 }
 
 // DefaultConfig returns the CLI's default root command configuration.
 func DefaultConfig() RootConfig {
 	return RootConfig{
-		DateAfter:         "10000101",
-		DateBefore:        "99991231",
+		Timezone:          defaultTimezone,
+		Format:            formatLines,
+		FeedTitle:         defaultFeedTitle,
+		SchemaVersion:     jsonSchemaVersionLatest,
 		Concurrency:       3,
 		PageConcurrency:   1,
 		Retries:           defaultRetries,
 		HTTPClientTimeout: defaultHTTPTimeout,
 		BaseURL:           defaultBaseURL,
+		SearchBaseURL:     defaultSearchBaseURL,
+		CacheTTL:          defaultCacheTTL,
+		WatchInterval:     defaultWatchInterval,
+		WatchJitter:       defaultWatchJitter,
+		WebhookFormat:     webhookFormatGeneric,
+		WebhookRetries:    defaultWebhookRetries,
+		WebhookRetryWait:  defaultSinkRetryWait,
+		ExecConcurrency:   1,
+		ExecRetries:       1,
+		ExecRetryWait:     defaultSinkRetryWait,
+		ExecTimeout:       defaultExecTimeout,
+		ServeAddr:         defaultServeAddr,
+		ResponseTTL:       defaultResponseTTL,
 		Version:           Version,
 	}
 }
@@ -79,6 +154,7 @@ func DefaultDeps() RootDeps {
 		},
 		OpenInputFile: func(path string) (io.ReadCloser, error) { return os.Open(path) },
 		IsTerminal:    defaultIsTerminal,
+		Now:           time.Now,
 	}
 }
 
@@ -89,7 +165,7 @@ func NewRootCommand(cfg RootConfig, deps RootDeps) *cobra.Command {
 
 	cmd := &cobra.Command{
 		Use:           "go-nico-list",
-		Short:         "niconico {user}/video url get video list",
+		Short:         "niconico {user}/video, mylist, series, or channel url get video list",
 		Args:          cobra.ArbitraryArgs,
 		SilenceUsage:  true,
 		SilenceErrors: true,
@@ -98,8 +174,30 @@ func NewRootCommand(cfg RootConfig, deps RootDeps) *cobra.Command {
 	cmd.SetOut(deps.Stdout)
 	cmd.SetErr(deps.Stderr)
 	cmd.Flags().IntVarP(&cfg.Comment, "comment", "c", cfg.Comment, "lower comment limit `number`")
-	cmd.Flags().StringVarP(&cfg.DateAfter, "dateafter", "a", cfg.DateAfter, "date `YYYYMMDD` after")
-	cmd.Flags().StringVarP(&cfg.DateBefore, "datebefore", "b", cfg.DateBefore, "date `YYYYMMDD` before")
+	cmd.Flags().IntVar(&cfg.MinViews, "min-views", cfg.MinViews, "minimum view count (0 disables)")
+	cmd.Flags().IntVar(&cfg.MaxViews, "max-views", cfg.MaxViews, "maximum view count (0 disables)")
+	cmd.Flags().IntVar(&cfg.MinLikes, "min-likes", cfg.MinLikes, "minimum like count (0 disables)")
+	cmd.Flags().IntVar(&cfg.MaxLikes, "max-likes", cfg.MaxLikes, "maximum like count (0 disables)")
+	cmd.Flags().IntVar(&cfg.MinMylists, "min-mylists", cfg.MinMylists, "minimum mylist count (0 disables)")
+	cmd.Flags().IntVar(&cfg.MaxMylists, "max-mylists", cfg.MaxMylists, "maximum mylist count (0 disables)")
+	cmd.Flags().IntVar(&cfg.MinComment, "min-comment", cfg.MinComment, "minimum comment count, inclusive (0 disables)")
+	cmd.Flags().IntVar(&cfg.MaxComment, "max-comment", cfg.MaxComment, "maximum comment count (0 disables)")
+	cmd.Flags().DurationVar(&cfg.MinDuration, "min-duration", cfg.MinDuration, "minimum video length (0 disables)")
+	cmd.Flags().DurationVar(&cfg.MaxDuration, "max-duration", cfg.MaxDuration, "maximum video length (0 disables)")
+	cmd.Flags().StringVar(&cfg.FilterExpr, "filter", cfg.FilterExpr, "filter expression evaluated per video, e.g. 'views > 1000 && !channel'")
+	cmd.Flags().BoolVar(&cfg.ExcludeChannel, "exclude-channel", cfg.ExcludeChannel, "drop channel videos")
+	cmd.Flags().BoolVar(&cfg.ExcludePaid, "exclude-paid", cfg.ExcludePaid, "drop videos that require payment")
+	cmd.Flags().BoolVar(&cfg.ExcludeSensitive, "exclude-sensitive", cfg.ExcludeSensitive, "drop videos with sensitive-content masking")
+	cmd.Flags().StringVar(&cfg.OwnerType, "owner-type", cfg.OwnerType, "keep only videos owned by a user or channel (user|channel)")
+	cmd.Flags().StringVar(&cfg.TitleMatch, "title-match", cfg.TitleMatch, "keep only videos whose title matches this regex")
+	cmd.Flags().StringVar(&cfg.TitleExclude, "title-exclude", cfg.TitleExclude, "drop videos whose title matches this regex")
+	cmd.Flags().StringVar(&cfg.DescMatch, "desc-match", cfg.DescMatch, "keep only videos whose description matches this regex")
+	cmd.Flags().StringVar(&cfg.DescExclude, "desc-exclude", cfg.DescExclude, "drop videos whose description matches this regex")
+	cmd.Flags().BoolVar(&cfg.IgnoreCase, "ignore-case", cfg.IgnoreCase, "match title and description regexes case-insensitively")
+	cmd.Flags().BoolVar(&cfg.NormalizeWidth, "normalize-width", cfg.NormalizeWidth, "fold full-width/half-width characters before title and description matching")
+	cmd.Flags().StringVarP(&cfg.DateAfter, "dateafter", "a", cfg.DateAfter, "keep videos registered on or after this `date` (YYYYMMDD, YYYY-MM-DD, RFC3339, 7d, 24h, today, last-monday; empty is open)")
+	cmd.Flags().StringVarP(&cfg.DateBefore, "datebefore", "b", cfg.DateBefore, "keep videos registered on or before this `date` (same forms as --dateafter; empty is open)")
+	cmd.Flags().StringVar(&cfg.Timezone, "timezone", cfg.Timezone, "IANA time zone for day boundaries and relative dates")
 	cmd.Flags().BoolVarP(&cfg.URL, "url", "u", cfg.URL, "output id add url")
 	cmd.Flags().IntVarP(&cfg.Concurrency, "concurrency", "n", cfg.Concurrency, "number of concurrent requests")
 	cmd.Flags().IntVar(&cfg.PageConcurrency, "page-concurrency", cfg.PageConcurrency, "number of concurrent page requests per target")
@@ -107,29 +205,74 @@ func NewRootCommand(cfg RootConfig, deps RootDeps) *cobra.Command {
 	cmd.Flags().IntVar(&cfg.Retries, "retries", cfg.Retries, "number of retries for requests")
 	cmd.Flags().Float64Var(&cfg.RateLimit, "rate-limit", cfg.RateLimit, "maximum requests per second (0 disables)")
 	cmd.Flags().DurationVar(&cfg.MinInterval, "min-interval", cfg.MinInterval, "minimum interval between requests (0 disables)")
+	cmd.Flags().StringVar(&cfg.SortKey, "sort-key", cfg.SortKey, "server-side sort `key` for user, mylist, and channel pages (registeredAt, viewCount, ...)")
+	cmd.Flags().StringVar(&cfg.SortOrder, "sort-order", cfg.SortOrder, "server-side sort `order` (asc or desc, default desc)")
 	cmd.Flags().StringVar(&cfg.InputFilePath, "input-file", cfg.InputFilePath, "read inputs from file (newline-separated)")
+	cmd.Flags().StringArrayVar(&cfg.Queries, "query", cfg.Queries, "search videos by `keyword` (repeatable)")
 	cmd.Flags().BoolVar(&cfg.ReadStdin, "stdin", cfg.ReadStdin, "read inputs from stdin (newline-separated)")
 	cmd.Flags().StringVar(&cfg.LogFilePath, "logfile", cfg.LogFilePath, "log output file path")
+	cmd.Flags().StringVar(&cfg.SinceOutput, "since-output", cfg.SinceOutput, "print IDs added to and removed from each target since this previous JSON or line output `file`")
+	cmd.Flags().StringVar(&cfg.SaveOutput, "save-output", cfg.SaveOutput, "with since-output, also write this run's JSON output atomically to `file` (may be the since-output file)")
+	cmd.Flags().StringVar(&cfg.StateFilePath, "state-file", cfg.StateFilePath, "per-target checkpoint file for incremental runs")
+	cmd.Flags().StringVar(&cfg.MetricsFile, "metrics-file", cfg.MetricsFile, "write Prometheus metrics to this `file` for the node_exporter textfile collector")
 	cmd.Flags().BoolVar(&cfg.ForceProgress, "progress", cfg.ForceProgress, "force enable progress output")
 	cmd.Flags().BoolVar(&cfg.NoProgress, "no-progress", cfg.NoProgress, "disable progress output")
 	cmd.Flags().BoolVar(&cfg.StrictInput, "strict", cfg.StrictInput, "return non-zero if any input is invalid")
 	cmd.Flags().BoolVar(&cfg.BestEffort, "best-effort", cfg.BestEffort, "always exit 0 while logging fetch errors")
 	cmd.Flags().BoolVar(&cfg.DedupeOutput, "dedupe", cfg.DedupeOutput, "remove duplicate output IDs before output")
 	cmd.Flags().BoolVar(&cfg.NoSortOutput, "no-sort", cfg.NoSortOutput, "skip sorting output IDs for faster output")
-	cmd.Flags().BoolVar(&cfg.JSONOutput, "json", cfg.JSONOutput, "emit JSON output to stdout")
+	cmd.Flags().BoolVar(&cfg.JSONOutput, "json", cfg.JSONOutput, "emit JSON output to stdout (same as --format json)")
+	cmd.Flags().IntVar(&cfg.SchemaVersion, "schema-version", cfg.SchemaVersion, "JSON output schema `version` (1 is the original payload without schema_version)")
+	cmd.Flags().BoolVar(&cfg.PrintJSONSchema, "json-schema", cfg.PrintJSONSchema, "print the JSON Schema for --schema-version and exit")
+	cmd.Flags().StringVar(&cfg.Format, "format", cfg.Format, "output `format`: lines, json, csv, tsv, ndjson, template, m3u, batch, atom, rss, html, or markdown")
+	cmd.Flags().StringVar(&cfg.Template, "template", cfg.Template, "render each video with this Go text/template (\\t and \\n are expanded)")
+	cmd.Flags().StringVar(&cfg.TemplateFile, "template-file", cfg.TemplateFile, "render each video with the Go text/template in this `file`")
+	cmd.Flags().BoolVar(&cfg.OutputHints, "output-hints", cfg.OutputHints, "add a # out=<owner>/<title> [<id>] comment line after each URL in batch output")
+	cmd.Flags().StringVar(&cfg.FeedTitle, "feed-title", cfg.FeedTitle, "title of atom and rss feeds")
+	cmd.Flags().StringVar(&cfg.FeedDir, "feed-dir", cfg.FeedDir, "write one atom or rss feed per target into this `directory` instead of stdout")
+	cmd.Flags().StringSliceVar(&cfg.Columns, "columns", cfg.Columns, "comma-separated metadata columns for csv, tsv, and ndjson output")
+	cmd.PersistentFlags().StringVar(&cfg.CacheDir, "cache-dir", cfg.CacheDir, "cache API responses in `dir` (empty disables)")
+	cmd.PersistentFlags().DurationVar(&cfg.CacheTTL, "cache-ttl", cfg.CacheTTL, "serve cached responses without revalidation for this long")
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		return runRootCmdWithConfig(cmd, args, &cfg, deps)
 	}
+	cmd.AddCommand(newCacheCommand(&cfg))
+	cmd.AddCommand(newWatchCommand(cmd, &cfg, deps))
+	cmd.AddCommand(newServeCommand(cmd, &cfg, deps))
 	return cmd
 }
 
 func normalizeRootConfig(cfg RootConfig) RootConfig {
 	defaults := DefaultConfig()
-	if cfg.DateAfter == "" {
-		cfg.DateAfter = defaults.DateAfter
+	if cfg.Timezone == "" {
+		cfg.Timezone = defaults.Timezone
 	}
-	if cfg.DateBefore == "" {
-		cfg.DateBefore = defaults.DateBefore
+	if cfg.SchemaVersion == 0 {
+		cfg.SchemaVersion = defaults.SchemaVersion
+	}
+	if cfg.FeedTitle == "" {
+		cfg.FeedTitle = defaults.FeedTitle
+	}
+	if cfg.WatchInterval == 0 {
+		cfg.WatchInterval = defaults.WatchInterval
+	}
+	if cfg.WebhookFormat == "" {
+		cfg.WebhookFormat = defaults.WebhookFormat
+	}
+	if cfg.WebhookRetries == 0 {
+		cfg.WebhookRetries = defaults.WebhookRetries
+	}
+	if cfg.ExecConcurrency == 0 {
+		cfg.ExecConcurrency = defaults.ExecConcurrency
+	}
+	if cfg.ExecRetries == 0 {
+		cfg.ExecRetries = defaults.ExecRetries
+	}
+	if cfg.ExecTimeout == 0 {
+		cfg.ExecTimeout = defaults.ExecTimeout
+	}
+	if cfg.ServeAddr == "" {
+		cfg.ServeAddr = defaults.ServeAddr
 	}
 	if cfg.Concurrency == 0 {
 		cfg.Concurrency = defaults.Concurrency
@@ -146,6 +289,9 @@ func normalizeRootConfig(cfg RootConfig) RootConfig {
 	if cfg.BaseURL == "" {
 		cfg.BaseURL = defaults.BaseURL
 	}
+	if cfg.SearchBaseURL == "" {
+		cfg.SearchBaseURL = defaults.SearchBaseURL
+	}
 	if cfg.Version == "" {
 		cfg.Version = defaults.Version
 	}
@@ -175,5 +321,8 @@ func normalizeRootDeps(deps RootDeps) RootDeps {
 	if deps.IsTerminal == nil {
 		deps.IsTerminal = defaults.IsTerminal
 	}
+	if deps.Now == nil {
+		deps.Now = defaults.Now
+	}
 	return deps
 }
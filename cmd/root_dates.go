@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	// Embed the time zone database so --timezone works on systems without one (e.g. Windows).
+	_ "time/tzdata"
+)
+
+// dateRange is the parsed --dateafter/--datebefore pair; zero values leave a bound open.
+type dateRange struct {
+	// after is the inclusive lower bound on the registration time.
+	after time.Time
+	// beforeDay is the inclusive last registration day, at midnight in the configured time zone.
+	beforeDay time.Time
+	// until is the inclusive upper bound on the registration time.
+	until time.Time
+}
+
+// dateBound is a single parsed date value: either a calendar day at midnight or an exact instant.
+type dateBound struct {
+	t   time.Time
+	day bool
+}
+
+var (
+	relativeDaysPattern = regexp.MustCompile(`^(\d{1,5})([dw])$`)
+	weekdayNames        = map[string]time.Weekday{
+		"sunday":    time.Sunday,
+		"monday":    time.Monday,
+		"tuesday":   time.Tuesday,
+		"wednesday": time.Wednesday,
+		"thursday":  time.Thursday,
+		"friday":    time.Friday,
+		"saturday":  time.Saturday,
+	}
+)
+
+// parseDateRange parses --dateafter and --datebefore relative to now in loc.
+func parseDateRange(after, before string, now time.Time, loc *time.Location) (dateRange, error) {
+	parsedAfter, err := parseDateBound(after, now, loc)
+	if err != nil {
+		return dateRange{}, errors.New("dateafter format error")
+	}
+	parsedBefore, err := parseDateBound(before, now, loc)
+	if err != nil {
+		return dateRange{}, errors.New("datebefore format error")
+	}
+	dates := dateRange{after: parsedAfter.t}
+	outOfOrder := false
+	switch {
+	case parsedBefore.t.IsZero():
+	case parsedBefore.day:
+		dates.beforeDay = parsedBefore.t
+		outOfOrder = !dates.after.Before(parsedBefore.t.AddDate(0, 0, 1))
+	default:
+		dates.until = parsedBefore.t
+		outOfOrder = dates.after.After(parsedBefore.t)
+	}
+	if outOfOrder {
+		return dateRange{}, errors.New("dateafter must be on or before datebefore")
+	}
+	return dates, nil
+}
+
+// parseDateBound parses one date flag value; an empty value returns the zero bound.
+//
+// Accepted forms are YYYYMMDD and YYYY-MM-DD days, RFC3339 timestamps, durations before now
+// such as 7d, 2w, 24h, or 90m, and the keywords now, today, yesterday, and last-<weekday>,
+// optionally prefixed with "since:".
+func parseDateBound(value string, now time.Time, loc *time.Location) (dateBound, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "since:")
+	if value == "" {
+		return dateBound{}, nil
+	}
+	for _, layout := range []string{"20060102", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return dateBound{t: t, day: true}, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return dateBound{t: t}, nil
+	}
+	value = strings.ToLower(value)
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	switch value {
+	case "now":
+		return dateBound{t: now}, nil
+	case "today":
+		return dateBound{t: today, day: true}, nil
+	case "yesterday":
+		return dateBound{t: today.AddDate(0, 0, -1), day: true}, nil
+	}
+	if name, ok := strings.CutPrefix(value, "last-"); ok {
+		weekday, ok := weekdayNames[name]
+		if !ok {
+			return dateBound{}, fmt.Errorf("unknown weekday %q", name)
+		}
+		daysAgo := (int(today.Weekday())-int(weekday)+6)%7 + 1
+		return dateBound{t: today.AddDate(0, 0, -daysAgo), day: true}, nil
+	}
+	if match := relativeDaysPattern.FindStringSubmatch(value); match != nil {
+		count, err := strconv.Atoi(match[1])
+		if err != nil {
+			return dateBound{}, err
+		}
+		if match[2] == "w" {
+			count *= 7
+		}
+		return dateBound{t: now.AddDate(0, 0, -count)}, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return dateBound{}, fmt.Errorf("invalid date %q", value)
+	}
+	return dateBound{t: now.Add(-duration)}, nil
+}
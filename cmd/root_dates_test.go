@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateBound(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	// Wednesday 2024-05-15 10:30 in Tokyo.
+	now := time.Date(2024, 5, 15, 1, 30, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+		day   bool
+	}{
+		{value: "", want: time.Time{}},
+		{value: "20240501", want: time.Date(2024, 5, 1, 0, 0, 0, 0, tokyo), day: true},
+		{value: "2024-05-01", want: time.Date(2024, 5, 1, 0, 0, 0, 0, tokyo), day: true},
+		{value: "2024-05-01T12:00:00Z", want: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{value: "7d", want: time.Date(2024, 5, 8, 10, 30, 0, 0, tokyo)},
+		{value: "2w", want: time.Date(2024, 5, 1, 10, 30, 0, 0, tokyo)},
+		{value: "24h", want: time.Date(2024, 5, 14, 10, 30, 0, 0, tokyo)},
+		{value: "now", want: now},
+		{value: "today", want: time.Date(2024, 5, 15, 0, 0, 0, 0, tokyo), day: true},
+		{value: "yesterday", want: time.Date(2024, 5, 14, 0, 0, 0, 0, tokyo), day: true},
+		{value: "since:last-monday", want: time.Date(2024, 5, 13, 0, 0, 0, 0, tokyo), day: true},
+		{value: "last-wednesday", want: time.Date(2024, 5, 8, 0, 0, 0, 0, tokyo), day: true},
+		{value: "Last-Thursday", want: time.Date(2024, 5, 9, 0, 0, 0, 0, tokyo), day: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDateBound(tt.value, now, tokyo)
+		if err != nil {
+			t.Fatalf("parseDateBound(%q): %v", tt.value, err)
+		}
+		if !got.t.Equal(tt.want) || got.day != tt.day {
+			t.Fatalf("parseDateBound(%q) = %v (day=%v), want %v (day=%v)", tt.value, got.t, got.day, tt.want, tt.day)
+		}
+	}
+	for _, value := range []string{"2024/05/01", "-24h", "last-someday", "7x", "tomorrow"} {
+		if _, err := parseDateBound(value, now, tokyo); err == nil {
+			t.Fatalf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestParseDateRangeOrder(t *testing.T) {
+	now := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		after, before string
+		wantErr       bool
+	}{
+		{after: "", before: ""},
+		{after: "20240501", before: ""},
+		{after: "", before: "20240501"},
+		{after: "2024-05-01T12:00:00Z", before: "20240501"},
+		{after: "2024-05-01T12:00:00Z", before: "2024-05-01T12:00:00Z"},
+		{after: "2024-05-01T12:00:01Z", before: "2024-05-01T12:00:00Z", wantErr: true},
+		{after: "20240502", before: "20240501", wantErr: true},
+		{after: "7d", before: "14d", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := parseDateRange(tt.after, tt.before, now, time.UTC)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseDateRange(%q, %q): unexpected error %v", tt.after, tt.before, err)
+		}
+	}
+}
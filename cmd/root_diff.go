@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+// removedReason flags IDs that were listed before but are missing now.
+const removedReason = "possibly_deleted_or_private"
+
+// previousOutput is a parsed --since-output file.
+type previousOutput struct {
+	// perTarget is set for JSON output, whose items are keyed by target; line output only has items.
+	perTarget bool
+	targets   map[inputTarget][]string
+	items     []string
+}
+
+// diffRemoved is an ID that disappeared since the previous output.
+type diffRemoved struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// targetDiff lists the IDs added to and removed from one target; an empty type and ID cover all targets.
+type targetDiff struct {
+	Type    string        `json:"type"`
+	ID      string        `json:"id"`
+	Parent  *targetParent `json:"parent,omitempty"`
+	Added   []string      `json:"added"`
+	Removed []diffRemoved `json:"removed"`
+}
+
+// diffOutput is the --since-output JSON document.
+type diffOutput struct {
+	Since        string       `json:"since"`
+	Targets      []targetDiff `json:"targets"`
+	AddedCount   int          `json:"added_count"`
+	RemovedCount int          `json:"removed_count"`
+}
+
+// loadPreviousOutput reads a previous --json payload (any schema version) or line output (IDs or watch URLs).
+func loadPreviousOutput(path string) (previousOutput, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return previousOutput{}, fmt.Errorf("since-output: %w", err)
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var payload struct {
+			Targets []struct {
+				Type  string   `json:"type"`
+				ID    string   `json:"id"`
+				Items []string `json:"items"`
+			} `json:"targets"`
+			Items []string `json:"items"`
+		}
+		if err := json.Unmarshal(trimmed, &payload); err != nil {
+			return previousOutput{}, fmt.Errorf("since-output: %w", err)
+		}
+		previous := previousOutput{perTarget: true, targets: make(map[inputTarget][]string, len(payload.Targets))}
+		for _, target := range payload.Targets {
+			key := inputTarget{Type: target.Type, ID: target.ID}
+			previous.targets[key] = append(previous.targets[key], normalizeOutputList(target.Items)...)
+		}
+		previous.items = normalizeOutputList(payload.Items)
+		return previous, nil
+	}
+	var previous previousOutput
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			previous.items = append(previous.items, normalizeOutputID(line))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return previousOutput{}, fmt.Errorf("since-output: %w", err)
+	}
+	return previous, nil
+}
+
+// diffTargetResults compares sorted target results with the previous output.
+// Failed targets are skipped because their items may be incomplete; without per-target data,
+// all targets are compared as one list and removals are only reported when every target succeeded.
+func diffTargetResults(previous previousOutput, results []targetResult) []targetDiff {
+	diffs := make([]targetDiff, 0)
+	if !previous.perTarget {
+		current := make([]string, 0)
+		failed := false
+		for _, result := range results {
+			if result.Error != "" {
+				failed = true
+				continue
+			}
+			current = append(current, result.Items...)
+		}
+		diff := diffItems(previous.items, current)
+		if failed {
+			diff.Removed = []diffRemoved{}
+		}
+		if len(diff.Added) > 0 || len(diff.Removed) > 0 {
+			diffs = append(diffs, diff)
+		}
+		return diffs
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			continue
+		}
+		diff := diffItems(previous.targets[inputTarget{Type: result.Type, ID: result.ID}], result.Items)
+		if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+			continue
+		}
+		diff.Type = result.Type
+		diff.ID = result.ID
+		diff.Parent = result.Parent
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// diffItems returns the IDs only in current as added and those only in previous as removed, in video ID order.
+func diffItems(previous, current []string) targetDiff {
+	previousSet := make(map[string]struct{}, len(previous))
+	for _, id := range previous {
+		previousSet[id] = struct{}{}
+	}
+	currentSet := make(map[string]struct{}, len(current))
+	diff := targetDiff{Added: []string{}, Removed: []diffRemoved{}}
+	for _, id := range normalizeOutputList(current) {
+		if _, ok := currentSet[id]; ok {
+			continue
+		}
+		currentSet[id] = struct{}{}
+		if _, ok := previousSet[id]; !ok {
+			diff.Added = append(diff.Added, id)
+		}
+	}
+	removed := make([]string, 0)
+	for id := range previousSet {
+		if _, ok := currentSet[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	niconico.NiconicoSort(diff.Added)
+	niconico.NiconicoSort(removed)
+	for _, id := range removed {
+		diff.Removed = append(diff.Removed, diffRemoved{ID: id, Reason: removedReason})
+	}
+	return diff
+}
+
+// writeDiffOutput writes the diff as JSON, or as "+"/"-" lines of target, ID, and (for removals) the reason.
+func writeDiffOutput(out io.Writer, cfg *RootConfig, diffs []targetDiff) error {
+	if outputFormatFor(cfg) == formatJSON {
+		payload := diffOutput{Since: cfg.SinceOutput, Targets: diffs}
+		for _, diff := range diffs {
+			payload.AddedCount += len(diff.Added)
+			payload.RemovedCount += len(diff.Removed)
+		}
+		return json.NewEncoder(out).Encode(payload)
+	}
+	writer := bufio.NewWriter(out)
+	for _, diff := range diffs {
+		target := "*"
+		if diff.Type != "" {
+			target = diff.Type + "/" + diff.ID
+		}
+		for _, id := range diff.Added {
+			if _, err := writer.WriteString("+\t" + target + "\t" + diffLineID(cfg, id) + "\n"); err != nil {
+				return err
+			}
+		}
+		for _, removed := range diff.Removed {
+			if _, err := writer.WriteString("-\t" + target + "\t" + diffLineID(cfg, removed.ID) + "\t" + removed.Reason + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+// writeSavedOutput atomically replaces path with payload encoded like --json output.
+func writeSavedOutput(path string, payload any) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(payload); err != nil {
+		return err
+	}
+	if err := writeFileAtomically(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("save-output %s: %w", path, err)
+	}
+	return nil
+}
+
+// diffLineID applies --url to a diff line ID.
+func diffLineID(cfg *RootConfig, id string) string {
+	if cfg.URL {
+		return watchURL(id)
+	}
+	return id
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestDiffTargetResultsPerTarget(t *testing.T) {
+	path := writeTestFile(t, "prev.json", `{"schema_version":2,"targets":[`+
+		`{"type":"user","id":"1","items":["sm1","sm9"]},`+
+		`{"type":"mylist","id":"2","items":["sm5"]},`+
+		`{"type":"series","id":"3","items":["sm7"]}]}`)
+	previous, err := loadPreviousOutput(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results := []targetResult{
+		{Type: targetTypeMylist, ID: "2", Parent: &targetParent{Type: targetTypeUserMylists, ID: "1"}, Items: []string{"sm5"}},
+		{Type: targetTypeSeries, ID: "3", Items: []string{}, Error: "series 3: timeout"},
+		{Type: targetTypeUser, ID: "1", Items: []string{"sm10", "sm1", "sm2", "sm2"}},
+		{Type: targetTypeUser, ID: "4", Items: []string{"sm4"}},
+	}
+	want := []targetDiff{
+		{Type: targetTypeUser, ID: "1", Added: []string{"sm2", "sm10"}, Removed: []diffRemoved{{ID: "sm9", Reason: removedReason}}},
+		{Type: targetTypeUser, ID: "4", Added: []string{"sm4"}, Removed: []diffRemoved{}},
+	}
+	if got := diffTargetResults(previous, results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDiffTargetResultsLineOutput(t *testing.T) {
+	path := writeTestFile(t, "prev.txt", "https://www.nicovideo.jp/watch/sm1\n\nsm2\n")
+	previous, err := loadPreviousOutput(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if previous.perTarget || !reflect.DeepEqual(previous.items, []string{"sm1", "sm2"}) {
+		t.Fatalf("unexpected previous output: %+v", previous)
+	}
+	results := []targetResult{{Type: targetTypeUser, ID: "1", Items: []string{"sm1", "sm3"}}}
+	want := []targetDiff{{Added: []string{"sm3"}, Removed: []diffRemoved{{ID: "sm2", Reason: removedReason}}}}
+	if got := diffTargetResults(previous, results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff: %+v", got)
+	}
+
+	// A failed target could hide any ID, so removals are not reported.
+	results = append(results, targetResult{Type: targetTypeUser, ID: "2", Error: "boom"})
+	want[0].Removed = []diffRemoved{}
+	if got := diffTargetResults(previous, results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff with failed target: %+v", got)
+	}
+}
+
+func TestSinceOutputValidation(t *testing.T) {
+	path := writeTestFile(t, "prev.txt", "sm1\n")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--since-output", path, "--format", "csv"}, want: "since-output requires lines or json output"},
+		{args: []string{"--since-output", path, "--state-file", "state.json"}, want: "since-output cannot be combined with state-file"},
+		{args: []string{"--since-output", path, "--dateafter", "7d"}, want: "since-output cannot be combined with dateafter or datebefore"},
+		{args: []string{"--since-output", path, "--datebefore", "20240101"}, want: "since-output cannot be combined with dateafter or datebefore"},
+		{args: []string{"--save-output", "out.json"}, want: "save-output requires since-output"},
+		{args: []string{"--since-output", path + ".missing"}, want: "since-output: open " + path + ".missing: no such file or directory"},
+		{args: []string{"--since-output", writeTestFile(t, "bad.json", "{")}, want: "since-output: unexpected end of JSON input"},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "nicovideo.jp/user/1")
+		_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), args...)
+		if err == nil || err.Error() != tt.want {
+			t.Fatalf("unexpected error for %v: %v", tt.args, err)
+		}
+	}
+}
+
+func TestRunRootCmdSinceOutput(t *testing.T) {
+	server := newFormatTestServer(t)
+	path := writeTestFile(t, "prev.json", `{"targets":[{"type":"user","id":"1","items":["sm0","sm1"]}]}`)
+	cfg := testFetchConfig(server.URL)
+	cfg.NoSortOutput = true
+	out, errOut, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "--since-output", path, "--url", "nicovideo.jp/user/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "+\tuser/1\thttps://www.nicovideo.jp/watch/sm2\n-\tuser/1\thttps://www.nicovideo.jp/watch/sm0\tpossibly_deleted_or_private\n"
+	if got := out.String(); got != want {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if errOut.Len() == 0 {
+		t.Fatal("expected summary on stderr")
+	}
+
+	out, _, err = executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), "--since-output", path, "--json", "nicovideo.jp/user/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var payload diffOutput
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if payload.Since != path || payload.AddedCount != 1 || payload.RemovedCount != 1 || len(payload.Targets) != 1 || payload.Targets[0].Removed[0].ID != "sm0" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestRunRootCmdSaveOutput(t *testing.T) {
+	var failing atomic.Bool
+	upstream := newFormatTestServer(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() && strings.HasPrefix(r.URL.Path, "/users/2/") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		upstream.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	path := writeTestFile(t, "last.json", `{"targets":[{"type":"user","id":"1","items":["sm1"]}]}`)
+	deps := newTestRootDeps()
+	deps.Now = fixedNow
+	args := []string{"--since-output", path, "--save-output", path, "nicovideo.jp/user/1", "nicovideo.jp/user/2"}
+
+	out, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), deps, args...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "+\tuser/1\tsm2\n+\tuser/2\tsm1\n+\tuser/2\tsm2\n"; out.String() != want {
+		t.Fatalf("unexpected diff: %q", out.String())
+	}
+	// The saved file is the --json payload of the same run, so the next diff starts from it.
+	want, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), deps, "--json", "nicovideo.jp/user/1", "nicovideo.jp/user/2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil || string(saved) != want.String() {
+		t.Fatalf("saved output differs from --json:\n%s\nwant:\n%s (%v)", saved, want.String(), err)
+	}
+	out, _, err = executeTestRootCommand(t, testFetchConfig(server.URL), deps, args...)
+	if err != nil || out.Len() != 0 {
+		t.Fatalf("expected no changes, got %q, %v", out.String(), err)
+	}
+
+	// A failed target leaves the previous file in place.
+	failing.Store(true)
+	if _, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), deps, append([]string{"--best-effort"}, args...)...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if after, err := os.ReadFile(path); err != nil || string(after) != string(saved) {
+		t.Fatalf("expected save-output to keep the previous file, got:\n%s (%v)", after, err)
+	}
+}
+
+func TestRunRootCmdSinceOutputInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	previous := `{"targets":[{"type":"user","id":"1","items":["sm1"]}]}`
+	path := writeTestFile(t, "last.json", previous)
+	cmd, out, _ := newTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps())
+	cmd.SetContext(ctx)
+	cmd.SetArgs([]string{"--since-output", path, "--save-output", path, "--retries", "1", "nicovideo.jp/user/1"})
+	// The cancelled fetch returns no videos, which must not be reported or saved as removals.
+	if err := cmd.Execute(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation error, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("unexpected diff: %q", out.String())
+	}
+	if after, err := os.ReadFile(path); err != nil || string(after) != previous {
+		t.Fatalf("expected save-output to keep the previous file, got:\n%s (%v)", after, err)
+	}
+}
@@ -9,12 +9,12 @@ import (
 	"sync"
 	"sync/atomic"
 
-	"github.com/sh4869221b/go-nico-list/internal/niconico"
+	"github.com/sh4869221b/go-nico-list/niconico"
 	"github.com/spf13/cobra"
 )
 
 type unorderedBatch struct {
-	items []string
+	videos []niconico.Video
 }
 
 type unorderedWriteResult struct {
@@ -26,7 +26,18 @@ func runRootCmdFastUnordered(cmd *cobra.Command, args []string, cfg *RootConfig,
 	if err := validateFlagsFor(cfg); err != nil {
 		return err
 	}
-	afterDate, beforeDate, err := parseDateRange(cfg.DateAfter, cfg.DateBefore)
+	if err := validateSearchExclusions(cfg, argTargets(args)); err != nil {
+		return err
+	}
+	formatter, err := newVideoFormatter(cfg)
+	if err != nil {
+		return err
+	}
+	filter, err := newFilter(cfg, deps.Now())
+	if err != nil {
+		return err
+	}
+	state, err := loadTargetState(cfg.StateFilePath)
 	if err != nil {
 		return err
 	}
@@ -48,8 +59,9 @@ func runRootCmdFastUnordered(cmd *cobra.Command, args []string, cfg *RootConfig,
 	defer cancel()
 
 	errWriter := errWriterFor(cmd)
-	stream := streamInputsWithConfig(ctx, cmd, args, cfg, deps)
-	limiter := niconico.NewRateLimiter(cfg.RateLimit, cfg.MinInterval)
+	metrics := newRunMetrics()
+	client := newNiconicoClient(cfg, runLogger, metrics)
+	stream := streamInputsWithConfig(ctx, cmd, args, cfg, deps, newTargetExpander(client))
 	var totalInputs int64
 	var validInputs int64
 	var invalidInputs int64
@@ -66,7 +78,7 @@ func runRootCmdFastUnordered(cmd *cobra.Command, args []string, cfg *RootConfig,
 
 	outputCh := make(chan unorderedBatch, cfg.Concurrency)
 	writeDone := make(chan unorderedWriteResult, 1)
-	go writeUnorderedOutput(outWriterFor(cmd), outputCh, cfg, cancel, writeDone)
+	go writeUnorderedOutput(outWriterFor(cmd), outputCh, cfg, formatter, cancel, writeDone)
 
 	sem := make(chan struct{}, cfg.Concurrency)
 	var wg sync.WaitGroup
@@ -91,7 +103,7 @@ func runRootCmdFastUnordered(cmd *cobra.Command, args []string, cfg *RootConfig,
 	inputClosed := false
 inputLoop:
 	for {
-		var input string
+		var streamed streamInput
 		select {
 		case <-ctx.Done():
 			break inputLoop
@@ -100,8 +112,9 @@ inputLoop:
 				inputClosed = true
 				break inputLoop
 			}
-			input = nextInput
+			streamed = nextInput
 		}
+		input := streamed.value
 		atomic.AddInt64(&totalInputs, 1)
 		if inputErr == nil {
 			select {
@@ -124,6 +137,13 @@ inputLoop:
 			addProgress()
 			continue
 		}
+		if streamed.err != nil {
+			atomic.AddInt64(&fetchErrCount, 1)
+			metrics.observeTarget(false)
+			errCh <- streamed.err
+			addProgress()
+			continue
+		}
 		select {
 		case sem <- struct{}{}:
 		case <-ctx.Done():
@@ -134,7 +154,8 @@ inputLoop:
 			defer wg.Done()
 			defer func() { <-sem }()
 			defer addProgress()
-			newList, err := fetchTargetListFastUnordered(ctx, target, cfg, afterDate, beforeDate, limiter, runLogger)
+			newList, listed, err := state.fetch(ctx, client, target, filter)
+			metrics.observeTarget(err == nil)
 			if err != nil {
 				atomic.AddInt64(&fetchErrCount, 1)
 				errCh <- err
@@ -145,7 +166,10 @@ inputLoop:
 				atomic.AddInt64(&fetchOKCount, 1)
 			}
 			select {
-			case outputCh <- unorderedBatch{items: newList}:
+			case outputCh <- unorderedBatch{videos: newList}:
+				if err == nil {
+					state.record(target, listed, deps.Now())
+				}
 			case <-ctx.Done():
 			}
 		}(target)
@@ -179,21 +203,27 @@ inputLoop:
 			return err
 		}
 	}
-	if _, err := fmt.Fprintf(
-		errWriter,
-		"summary inputs=%d valid=%d invalid=%d fetch_ok=%d fetch_err=%d output_count=%d\n",
-		atomic.LoadInt64(&totalInputs),
-		atomic.LoadInt64(&validInputs),
-		atomic.LoadInt64(&invalidInputs),
-		atomic.LoadInt64(&fetchOKCount),
-		atomic.LoadInt64(&fetchErrCount),
-		writeResult.count,
-	); err != nil {
+	summary := runSummary{
+		Inputs:      atomic.LoadInt64(&totalInputs),
+		Valid:       atomic.LoadInt64(&validInputs),
+		Invalid:     atomic.LoadInt64(&invalidInputs),
+		FetchOK:     atomic.LoadInt64(&fetchOKCount),
+		FetchErr:    atomic.LoadInt64(&fetchErrCount),
+		OutputCount: writeResult.count,
+	}
+	if _, err := fmt.Fprintln(errWriter, summary); err != nil {
+		return err
+	}
+	metrics.observeItems(writeResult.count)
+	if err := writeMetricsFile(cfg.MetricsFile, metrics, deps.Now()); err != nil {
 		return err
 	}
 	if writeResult.err != nil {
 		return writeResult.err
 	}
+	if err := state.save(); err != nil {
+		return err
+	}
 	if inputErr != nil {
 		return inputErr
 	}
@@ -221,37 +251,48 @@ func collectFetchErrors(runLogger *slog.Logger, errCh <-chan error, fetchErrCh c
 	close(fetchErrCh)
 }
 
-func writeUnorderedOutput(out io.Writer, outputCh <-chan unorderedBatch, cfg *RootConfig, cancel context.CancelFunc, done chan<- unorderedWriteResult) {
+func writeUnorderedOutput(out io.Writer, outputCh <-chan unorderedBatch, cfg *RootConfig, formatter videoFormatter, cancel context.CancelFunc, done chan<- unorderedWriteResult) {
 	seen := make(map[string]struct{})
 	if !cfg.DedupeOutput {
 		seen = nil
 	}
 	outputCount := 0
+	fail := func(err error) {
+		cancel()
+		done <- unorderedWriteResult{count: outputCount, err: err}
+	}
+	if err := formatter.writeHeader(out); err != nil {
+		fail(err)
+		return
+	}
 	for batch := range outputCh {
-		items := batch.items
+		videos := batch.videos
 		if seen != nil {
-			items = dedupeStreamingItems(items, seen)
+			videos = dedupeStreamingVideos(videos, seen)
 		}
-		if len(items) > 0 {
-			if err := writeLineOutput(out, items, cfg.URL); err != nil {
-				cancel()
-				done <- unorderedWriteResult{count: outputCount, err: err}
+		if len(videos) > 0 {
+			if err := formatter.writeVideos(out, videos); err != nil {
+				fail(err)
 				return
 			}
-			outputCount += len(items)
+			outputCount += len(videos)
 		}
 	}
+	if err := formatter.writeFooter(out); err != nil {
+		done <- unorderedWriteResult{count: outputCount, err: err}
+		return
+	}
 	done <- unorderedWriteResult{count: outputCount}
 }
 
-func dedupeStreamingItems(items []string, seen map[string]struct{}) []string {
-	unique := make([]string, 0, len(items))
-	for _, id := range items {
-		if _, ok := seen[id]; ok {
+func dedupeStreamingVideos(videos []niconico.Video, seen map[string]struct{}) []niconico.Video {
+	unique := make([]niconico.Video, 0, len(videos))
+	for _, video := range videos {
+		if _, ok := seen[video.ID]; ok {
 			continue
 		}
-		seen[id] = struct{}{}
-		unique = append(unique, id)
+		seen[video.ID] = struct{}{}
+		unique = append(unique, video)
 	}
 	return unique
 }
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+const (
+	formatAtom = "atom"
+	formatRSS  = "rss"
+
+	defaultFeedTitle = "go-nico-list"
+	nicoTopURL       = "https://www.nicovideo.jp/"
+	mediaRSSNS       = "http://search.yahoo.com/mrss/"
+)
+
+// feedInfo is the feed-level title, identifier, and web page link.
+type feedInfo struct {
+	title string
+	id    string
+	link  string
+}
+
+// combinedFeedInfo describes the single feed written to stdout.
+func combinedFeedInfo(cfg *RootConfig) feedInfo {
+	return feedInfo{
+		title: cfg.FeedTitle,
+		id:    "urn:go-nico-list:feed:" + url.QueryEscape(cfg.FeedTitle),
+		link:  nicoTopURL,
+	}
+}
+
+// targetFeedInfo describes the --feed-dir feed of one target.
+func targetFeedInfo(cfg *RootConfig, target inputTarget) feedInfo {
+	link := targetPageURL(target)
+	return feedInfo{
+		title: cfg.FeedTitle + " - " + target.Type + " " + target.ID,
+		id:    link,
+		link:  link,
+	}
+}
+
+// feedFormatter collects videos and writes them as an Atom or RSS feed, newest first, in writeFooter.
+type feedFormatter struct {
+	format string
+	info   feedInfo
+	videos []niconico.Video
+}
+
+func (f *feedFormatter) writeHeader(io.Writer) error { return nil }
+
+func (f *feedFormatter) writeVideos(_ io.Writer, videos []niconico.Video) error {
+	f.videos = append(f.videos, videos...)
+	return nil
+}
+
+func (f *feedFormatter) writeFooter(w io.Writer) error {
+	videos := slices.Clone(f.videos)
+	slices.SortStableFunc(videos, func(a, b niconico.Video) int {
+		return b.RegisteredAt.Compare(a.RegisteredAt)
+	})
+	// The newest entry dates the feed; an empty feed uses the Unix epoch so repeated runs stay identical.
+	updated := time.Unix(0, 0).UTC()
+	if len(videos) > 0 {
+		updated = videos[0].RegisteredAt
+	}
+	var doc any
+	if f.format == formatRSS {
+		doc = buildRSSFeed(f.info, updated, videos)
+	} else {
+		doc = buildAtomFeed(f.info, updated, videos)
+	}
+	writer := bufio.NewWriter(w)
+	if _, err := writer.WriteString(xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(writer)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := writer.WriteByte('\n'); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	MediaNS   string      `xml:"xmlns:media,attr"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Link      atomLink    `xml:"link"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string          `xml:"id"`
+	Title     string          `xml:"title"`
+	Links     []atomLink      `xml:"link"`
+	Published string          `xml:"published"`
+	Updated   string          `xml:"updated"`
+	Author    atomAuthor      `xml:"author"`
+	Summary   string          `xml:"summary,omitempty"`
+	Content   *atomContent    `xml:"content"`
+	Thumbnail *mediaThumbnail `xml:"media:thumbnail"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type mediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	MediaNS string     `xml:"xmlns:media,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string          `xml:"title"`
+	Link        string          `xml:"link"`
+	GUID        rssGUID         `xml:"guid"`
+	PubDate     string          `xml:"pubDate"`
+	Description string          `xml:"description"`
+	Enclosure   *rssEnclosure   `xml:"enclosure"`
+	Thumbnail   *mediaThumbnail `xml:"media:thumbnail"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// buildAtomFeed converts videos to Atom entries keyed by watch URL.
+func buildAtomFeed(info feedInfo, updated time.Time, videos []niconico.Video) atomFeed {
+	feed := atomFeed{
+		MediaNS:   mediaRSSNS,
+		ID:        info.id,
+		Title:     info.title,
+		Updated:   updated.Format(time.RFC3339),
+		Link:      atomLink{Href: info.link},
+		Generator: defaultFeedTitle,
+		Entries:   make([]atomEntry, 0, len(videos)),
+	}
+	for _, video := range videos {
+		link := watchURL(video.ID)
+		entry := atomEntry{
+			ID:        link,
+			Title:     feedVideoTitle(video),
+			Links:     []atomLink{{Href: link, Rel: "alternate"}},
+			Published: video.RegisteredAt.Format(time.RFC3339),
+			Updated:   video.RegisteredAt.Format(time.RFC3339),
+			Author:    feedVideoAuthor(video),
+			Summary:   video.ShortDescription,
+		}
+		if body := feedVideoHTML(video); body != "" {
+			entry.Content = &atomContent{Type: "html", Body: body}
+		}
+		if video.Thumbnail.URL != "" {
+			entry.Links = append(entry.Links, atomLink{Href: video.Thumbnail.URL, Rel: "enclosure", Type: "image/jpeg"})
+			entry.Thumbnail = &mediaThumbnail{URL: video.Thumbnail.URL}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+// buildRSSFeed converts videos to RSS 2.0 items keyed by watch URL.
+func buildRSSFeed(info feedInfo, updated time.Time, videos []niconico.Video) rssFeed {
+	channel := rssChannel{
+		Title:         info.title,
+		Link:          info.link,
+		Description:   info.title,
+		LastBuildDate: updated.Format(time.RFC1123Z),
+		Generator:     defaultFeedTitle,
+		Items:         make([]rssItem, 0, len(videos)),
+	}
+	for _, video := range videos {
+		link := watchURL(video.ID)
+		item := rssItem{
+			Title:       feedVideoTitle(video),
+			Link:        link,
+			GUID:        rssGUID{IsPermaLink: true, Value: link},
+			PubDate:     video.RegisteredAt.Format(time.RFC1123Z),
+			Description: feedVideoHTML(video),
+		}
+		if video.Thumbnail.URL != "" {
+			item.Enclosure = &rssEnclosure{URL: video.Thumbnail.URL, Type: "image/jpeg"}
+			item.Thumbnail = &mediaThumbnail{URL: video.Thumbnail.URL}
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	return rssFeed{Version: "2.0", MediaNS: mediaRSSNS, Channel: channel}
+}
+
+// feedVideoTitle returns the entry title, falling back to the video ID.
+func feedVideoTitle(video niconico.Video) string {
+	if video.Title == "" {
+		return video.ID
+	}
+	return video.Title
+}
+
+// feedVideoAuthor returns the owner as an Atom author; Atom requires a name, so unknown owners become "niconico".
+func feedVideoAuthor(video niconico.Video) atomAuthor {
+	author := atomAuthor{Name: video.Owner.Name}
+	if video.Owner.Type == niconico.OwnerTypeUser && video.Owner.ID != "" {
+		author.URI = targetPageURL(inputTarget{Type: targetTypeUser, ID: video.Owner.ID})
+	}
+	if author.Name == "" {
+		author.Name = video.Owner.ID
+	}
+	if author.Name == "" {
+		author.Name = "niconico"
+	}
+	return author
+}
+
+// feedVideoHTML returns the entry body: the linked thumbnail followed by the description.
+func feedVideoHTML(video niconico.Video) string {
+	var body bytes.Buffer
+	if video.Thumbnail.URL != "" {
+		body.WriteString(`<p><a href="` + html.EscapeString(watchURL(video.ID)) + `"><img src="` +
+			html.EscapeString(video.Thumbnail.URL) + `" alt="` + html.EscapeString(feedVideoTitle(video)) + `"></a></p>`)
+	}
+	if video.ShortDescription != "" {
+		body.WriteString("<p>" + html.EscapeString(video.ShortDescription) + "</p>")
+	}
+	return body.String()
+}
+
+// writeTargetFeeds writes one feed per successfully fetched target to dir as <type>-<id>.xml.
+// Failed targets keep their previous file so a transient error does not empty a feed.
+func writeTargetFeeds(dir string, cfg *RootConfig, results []targetResult) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			continue
+		}
+		target := inputTarget{Type: result.Type, ID: result.ID}
+		formatter := &feedFormatter{format: outputFormatFor(cfg), info: targetFeedInfo(cfg, target)}
+		var buf bytes.Buffer
+		if err := writeFormattedOutput(&buf, formatter, result.Videos); err != nil {
+			return err
+		}
+		name := result.Type + "-" + url.QueryEscape(result.ID) + ".xml"
+		if err := writeFileAtomically(filepath.Join(dir, name), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
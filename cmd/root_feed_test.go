@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAtomFeedFormatter(t *testing.T) {
+	cfg := newTestRootConfig()
+	cfg.Format = formatAtom
+	cfg.FeedTitle = "uploads"
+	got := formatWithConfig(t, cfg)
+	var feed atomFeed
+	if err := xml.Unmarshal([]byte(got), &feed); err != nil {
+		t.Fatalf("invalid atom: %v\n%s", err, got)
+	}
+	if feed.Title != "uploads" || feed.ID != "urn:go-nico-list:feed:uploads" || feed.Updated != "2024-01-02T03:04:05Z" {
+		t.Fatalf("unexpected feed header: %+v", feed)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("unexpected entries: %+v", feed.Entries)
+	}
+	// Entries are newest first; sm2 has no registration time.
+	first := feed.Entries[0]
+	if first.ID != "https://www.nicovideo.jp/watch/sm1" || first.Published != "2024-01-02T03:04:05Z" || first.Author.Name != "owner" {
+		t.Fatalf("unexpected first entry: %+v", first)
+	}
+	second := feed.Entries[1]
+	if second.Author.Name != "niconico" || second.Summary != "line\nbreak" || second.Content == nil || second.Content.Body != "<p>line\nbreak</p>" {
+		t.Fatalf("unexpected second entry: %+v", second)
+	}
+	if !strings.Contains(got, `xmlns:media="http://search.yahoo.com/mrss/"`) {
+		t.Fatalf("missing media namespace:\n%s", got)
+	}
+}
+
+func TestRSSFeedFormatterIncludesThumbnails(t *testing.T) {
+	cfg := newTestRootConfig()
+	cfg.Format = formatRSS
+	videos := formatTestVideos()
+	videos[0].Thumbnail.URL = "https://example.com/sm1.jpg"
+	formatter, err := newVideoFormatter(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out strings.Builder
+	if err := writeFormattedOutput(&out, formatter, videos); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	var feed rssFeed
+	if err := xml.Unmarshal([]byte(out.String()), &feed); err != nil {
+		t.Fatalf("invalid rss: %v\n%s", err, out.String())
+	}
+	item := feed.Channel.Items[0]
+	if item.GUID.Value != "https://www.nicovideo.jp/watch/sm1" || item.PubDate != "Tue, 02 Jan 2024 03:04:05 +0000" {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+	if item.Enclosure == nil || item.Enclosure.URL != "https://example.com/sm1.jpg" {
+		t.Fatalf("unexpected enclosure: %+v", item.Enclosure)
+	}
+	wantDescription := `<p><a href="https://www.nicovideo.jp/watch/sm1"><img src="https://example.com/sm1.jpg" alt="title, with &#34;quotes&#34;"></a></p>`
+	if item.Description != wantDescription {
+		t.Fatalf("unexpected description: %q", item.Description)
+	}
+	if !strings.Contains(out.String(), `<media:thumbnail url="https://example.com/sm1.jpg"></media:thumbnail>`) {
+		t.Fatalf("missing media thumbnail:\n%s", out.String())
+	}
+}
+
+func TestTargetPageURL(t *testing.T) {
+	tests := []struct {
+		target inputTarget
+		want   string
+	}{
+		{target: inputTarget{Type: targetTypeUser, ID: "1"}, want: "https://www.nicovideo.jp/user/1/video"},
+		{target: inputTarget{Type: targetTypeMylist, ID: "2"}, want: "https://www.nicovideo.jp/mylist/2"},
+		{target: inputTarget{Type: targetTypeChannel, ID: "ch3"}, want: "https://ch.nicovideo.jp/ch3"},
+		{target: inputTarget{Type: targetTypeTag, ID: "a b/c"}, want: "https://www.nicovideo.jp/tag/a%20b%2Fc"},
+		{target: inputTarget{Type: targetTypeUserSeries, ID: "4"}, want: "https://www.nicovideo.jp/user/4/series"},
+	}
+	for _, tt := range tests {
+		if got := targetPageURL(tt.target); got != tt.want {
+			t.Fatalf("targetPageURL(%+v) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestFeedDirValidation(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--feed-dir", "feeds"}, want: "feed-dir requires format atom or rss"},
+		{args: []string{"--format", "atom", "--feed-dir", "feeds", "--no-sort"}, want: "feed-dir cannot be combined with no-sort"},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "nicovideo.jp/user/1")
+		_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), args...)
+		if err == nil || err.Error() != tt.want {
+			t.Fatalf("unexpected error for %v: %v", tt.args, err)
+		}
+	}
+}
+
+func TestRunRootCmdFeedDirWritesOneFeedPerTarget(t *testing.T) {
+	server := newFormatTestServer(t)
+	dir := filepath.Join(t.TempDir(), "feeds")
+	out, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(),
+		"--format", "rss", "--feed-dir", dir, "nicovideo.jp/user/1", "nicovideo.jp/user/22")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("unexpected stdout: %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "fetch_ok=2") {
+		t.Fatalf("unexpected summary: %q", errOut.String())
+	}
+	for name, link := range map[string]string{
+		"user-1.xml":  "https://www.nicovideo.jp/user/1/video",
+		"user-22.xml": "https://www.nicovideo.jp/user/22/video",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		var feed rssFeed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			t.Fatalf("invalid rss in %s: %v", name, err)
+		}
+		if feed.Channel.Link != link || len(feed.Channel.Items) != 2 || feed.Channel.Items[0].Title != "second" {
+			t.Fatalf("unexpected feed %s: %+v", name, feed.Channel)
+		}
+	}
+}
@@ -3,27 +3,70 @@ package cmd
 import (
 	"context"
 	"log/slog"
-	"time"
 
-	"github.com/sh4869221b/go-nico-list/internal/niconico"
+	"github.com/sh4869221b/go-nico-list/niconico"
 )
 
-// fetchTargetListFastUnordered fetches all IDs for a target for unordered output.
-func fetchTargetListFastUnordered(
-	ctx context.Context,
-	target inputTarget,
-	cfg *RootConfig,
-	afterDate time.Time,
-	beforeDate time.Time,
-	limiter *niconico.RateLimiter,
-	runLogger *slog.Logger,
-) ([]string, error) {
+// newNiconicoClient builds the API client shared by all targets in a run.
+func newNiconicoClient(cfg *RootConfig, runLogger *slog.Logger, metrics *runMetrics) *niconico.Client {
+	var cache *niconico.DiskCache
+	if cfg.CacheDir != "" {
+		cache = niconico.NewDiskCache(cfg.CacheDir, cfg.CacheTTL)
+	}
+	return niconico.NewClient(
+		niconico.WithBaseURL(cfg.BaseURL),
+		niconico.WithSearchBaseURL(cfg.SearchBaseURL),
+		niconico.WithRetries(cfg.Retries),
+		niconico.WithTimeout(cfg.HTTPClientTimeout),
+		niconico.WithRateLimiter(niconico.NewRateLimiter(cfg.RateLimit, cfg.MinInterval)),
+		niconico.WithCache(cache),
+		niconico.WithPageConcurrency(cfg.PageConcurrency),
+		niconico.WithSort(niconico.SortKey(cfg.SortKey), niconico.SortOrder(cfg.SortOrder)),
+		niconico.WithLogger(runLogger),
+		niconico.WithObserver(metrics),
+	)
+}
+
+// fetchTargetList fetches all videos for a target.
+func fetchTargetList(ctx context.Context, client *niconico.Client, target inputTarget, filter niconico.Filter) ([]niconico.Video, error) {
 	switch target.Type {
 	case targetTypeUser:
-		return niconico.GetVideoList(ctx, target.ID, cfg.Comment, afterDate, beforeDate, cfg.BaseURL, cfg.Retries, cfg.HTTPClientTimeout, limiter, cfg.PageConcurrency, runLogger)
+		return client.GetVideoList(ctx, target.ID, filter)
 	case targetTypeMylist:
-		return niconico.GetMylistVideoList(ctx, target.ID, cfg.Comment, afterDate, beforeDate, cfg.BaseURL, cfg.Retries, cfg.HTTPClientTimeout, limiter, cfg.PageConcurrency, runLogger)
+		return client.GetMylistVideoList(ctx, target.ID, filter)
+	case targetTypeSeries:
+		return client.GetSeriesVideoList(ctx, target.ID, filter)
+	case targetTypeChannel:
+		return client.GetChannelVideoList(ctx, target.ID, filter)
+	case targetTypeTag:
+		return client.GetTagVideoList(ctx, target.ID, filter)
+	case targetTypeSearch:
+		return client.GetSearchVideoList(ctx, target.ID, filter)
 	default:
 		return nil, nil
 	}
 }
+
+// newTargetExpander returns a targetExpander that lists a user's public mylists or series.
+func newTargetExpander(client *niconico.Client) targetExpander {
+	return func(ctx context.Context, target inputTarget) ([]inputTarget, error) {
+		var lists []niconico.UserList
+		var err error
+		var childType string
+		switch target.Type {
+		case targetTypeUserMylists:
+			lists, err = client.GetUserMylists(ctx, target.ID)
+			childType = targetTypeMylist
+		case targetTypeUserSeries:
+			lists, err = client.GetUserSeries(ctx, target.ID)
+			childType = targetTypeSeries
+		default:
+			return nil, nil
+		}
+		children := make([]inputTarget, 0, len(lists))
+		for _, list := range lists {
+			children = append(children, inputTarget{Type: childType, ID: list.ID})
+		}
+		return children, err
+	}
+}
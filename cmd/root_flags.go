@@ -7,15 +7,19 @@ import (
 	"context"
 	"time"
 
+	"github.com/sh4869221b/go-nico-list/niconico"
 	"github.com/spf13/cobra"
 )
 
 var Version = "unset"
 
 const (
-	defaultBaseURL     = "https://nvapi.nicovideo.jp/v3"
-	defaultHTTPTimeout = 10 * time.Second
-	defaultRetries     = 10
+	defaultBaseURL       = niconico.DefaultBaseURL
+	defaultSearchBaseURL = niconico.DefaultSearchBaseURL
+	defaultHTTPTimeout   = niconico.DefaultTimeout
+	defaultRetries       = niconico.DefaultRetries
+	defaultCacheTTL      = 10 * time.Minute
+	defaultTimezone      = "Asia/Tokyo"
 )
 
 func Execute() {
@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+const (
+	formatLines  = "lines"
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatTSV    = "tsv"
+	formatNDJSON = "ndjson"
+)
+
+// videoFormatter writes videos in one --format; the header precedes the first batch and the footer follows the last.
+type videoFormatter interface {
+	writeHeader(w io.Writer) error
+	writeVideos(w io.Writer, videos []niconico.Video) error
+	writeFooter(w io.Writer) error
+}
+
+// videoFormatters maps --format names to formatter constructors; json is written by buildJSONOutput instead.
+var videoFormatters = map[string]func(cfg *RootConfig) (videoFormatter, error){
+	formatLines: func(cfg *RootConfig) (videoFormatter, error) {
+		return lineFormatter{withURL: cfg.URL}, nil
+	},
+	formatCSV: func(cfg *RootConfig) (videoFormatter, error) {
+		columns, err := selectVideoColumns(cfg.Columns, defaultTableColumns)
+		return &tableFormatter{columns: columns, comma: ','}, err
+	},
+	formatTSV: func(cfg *RootConfig) (videoFormatter, error) {
+		columns, err := selectVideoColumns(cfg.Columns, defaultTableColumns)
+		return &tableFormatter{columns: columns, comma: '\t'}, err
+	},
+	formatNDJSON: func(cfg *RootConfig) (videoFormatter, error) {
+		columns, err := selectVideoColumns(cfg.Columns, nil)
+		return ndjsonFormatter{columns: columns}, err
+	},
+	formatTemplate: newTemplateFormatter,
+	formatM3U: func(cfg *RootConfig) (videoFormatter, error) {
+		return m3uFormatter{}, nil
+	},
+	formatBatch: func(cfg *RootConfig) (videoFormatter, error) {
+		return batchFormatter{outputHints: cfg.OutputHints}, nil
+	},
+	formatAtom: func(cfg *RootConfig) (videoFormatter, error) {
+		return &feedFormatter{format: formatAtom, info: combinedFeedInfo(cfg)}, nil
+	},
+	formatRSS: func(cfg *RootConfig) (videoFormatter, error) {
+		return &feedFormatter{format: formatRSS, info: combinedFeedInfo(cfg)}, nil
+	},
+}
+
+// streamingFormats lists formats that are written as targets complete even without --no-sort.
+var streamingFormats = []string{formatNDJSON}
+
+// streamsOutput reports whether results are written as targets complete instead of sorted at the end.
+func streamsOutput(cfg *RootConfig) bool {
+	format := outputFormatFor(cfg)
+	if isRunFormat(format) || cfg.SinceOutput != "" {
+		return false
+	}
+	return cfg.NoSortOutput || slices.Contains(streamingFormats, format)
+}
+
+// outputFormatFor returns the effective --format, honoring --json, --template, and --template-file.
+func outputFormatFor(cfg *RootConfig) string {
+	if cfg.JSONOutput {
+		return formatJSON
+	}
+	if (cfg.Format == "" || cfg.Format == formatLines) && (cfg.Template != "" || cfg.TemplateFile != "") {
+		return formatTemplate
+	}
+	if cfg.Format == "" {
+		return formatLines
+	}
+	return cfg.Format
+}
+
+// outputFormatNames returns the supported --format names in sorted order.
+func outputFormatNames() []string {
+	names := append([]string{formatJSON}, reportFormats...)
+	for name := range videoFormatters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validateOutputFormat checks --format, --json, --columns, and the format-specific flags together.
+func validateOutputFormat(cfg *RootConfig) error {
+	if cfg.Format != "" && !isRunFormat(cfg.Format) && videoFormatters[cfg.Format] == nil {
+		return fmt.Errorf("format must be one of %s", strings.Join(outputFormatNames(), ", "))
+	}
+	if cfg.JSONOutput && cfg.Format != "" && cfg.Format != formatJSON && cfg.Format != formatLines {
+		return fmt.Errorf("json cannot be combined with format %s", cfg.Format)
+	}
+	format := outputFormatFor(cfg)
+	if (cfg.Template != "" || cfg.TemplateFile != "") && format != formatTemplate {
+		return fmt.Errorf("template cannot be combined with format %s", format)
+	}
+	if cfg.OutputHints && format != formatBatch {
+		return errors.New("output-hints requires format batch")
+	}
+	if cfg.SinceOutput != "" && format != formatLines && format != formatJSON {
+		return errors.New("since-output requires lines or json output")
+	}
+	if cfg.SinceOutput != "" && cfg.StateFilePath != "" {
+		return errors.New("since-output cannot be combined with state-file")
+	}
+	if cfg.SinceOutput != "" && (cfg.DateAfter != "" || cfg.DateBefore != "") {
+		// Videos leaving a date window would be reported as removed.
+		return errors.New("since-output cannot be combined with dateafter or datebefore")
+	}
+	if cfg.SaveOutput != "" && cfg.SinceOutput == "" {
+		return errors.New("save-output requires since-output")
+	}
+	if cfg.FeedDir != "" && format != formatAtom && format != formatRSS {
+		return errors.New("feed-dir requires format atom or rss")
+	}
+	if cfg.FeedDir != "" && cfg.NoSortOutput {
+		return errors.New("feed-dir cannot be combined with no-sort")
+	}
+	if len(cfg.Columns) > 0 && format != formatCSV && format != formatTSV && format != formatNDJSON {
+		return errors.New("columns requires format csv, tsv, or ndjson")
+	}
+	if isRunFormat(format) {
+		return nil
+	}
+	_, err := newVideoFormatter(cfg)
+	return err
+}
+
+// newVideoFormatter builds the formatter for the effective --format other than json and the report formats.
+func newVideoFormatter(cfg *RootConfig) (videoFormatter, error) {
+	return videoFormatters[outputFormatFor(cfg)](cfg)
+}
+
+// writeFormattedOutput writes a complete video list with formatter.
+func writeFormattedOutput(out io.Writer, formatter videoFormatter, videos []niconico.Video) error {
+	if err := formatter.writeHeader(out); err != nil {
+		return err
+	}
+	if len(videos) > 0 {
+		if err := formatter.writeVideos(out, videos); err != nil {
+			return err
+		}
+	}
+	return formatter.writeFooter(out)
+}
+
+// lineFormatter writes one video ID or watch URL per line.
+type lineFormatter struct {
+	withURL bool
+}
+
+func (f lineFormatter) writeHeader(io.Writer) error { return nil }
+
+func (f lineFormatter) writeVideos(w io.Writer, videos []niconico.Video) error {
+	return writeLineOutput(w, niconico.VideoIDs(videos), f.withURL)
+}
+
+func (f lineFormatter) writeFooter(io.Writer) error { return nil }
+
+// videoColumn is a named metadata field selectable with --columns.
+type videoColumn struct {
+	name  string
+	value func(niconico.Video) any
+}
+
+// videoColumns lists every selectable column in display order.
+var videoColumns = []videoColumn{
+	{name: "id", value: func(v niconico.Video) any { return v.ID }},
+	{name: "url", value: func(v niconico.Video) any { return watchURL(v.ID) }},
+	{name: "title", value: func(v niconico.Video) any { return v.Title }},
+	{name: "registered_at", value: func(v niconico.Video) any { return v.RegisteredAt }},
+	{name: "view", value: func(v niconico.Video) any { return v.Count.View }},
+	{name: "comment", value: func(v niconico.Video) any { return v.Count.Comment }},
+	{name: "mylist", value: func(v niconico.Video) any { return v.Count.Mylist }},
+	{name: "like", value: func(v niconico.Video) any { return v.Count.Like }},
+	{name: "duration", value: func(v niconico.Video) any { return v.Duration }},
+	{name: "short_description", value: func(v niconico.Video) any { return v.ShortDescription }},
+	{name: "thumbnail_url", value: func(v niconico.Video) any { return v.Thumbnail.URL }},
+	{name: "owner_type", value: func(v niconico.Video) any { return v.Owner.Type }},
+	{name: "owner_id", value: func(v niconico.Video) any { return v.Owner.ID }},
+	{name: "owner_name", value: func(v niconico.Video) any { return v.Owner.Name }},
+	{name: "series_id", value: func(v niconico.Video) any {
+		if v.Series == nil {
+			return nil
+		}
+		return v.Series.ID
+	}},
+	{name: "series_title", value: func(v niconico.Video) any {
+		if v.Series == nil {
+			return nil
+		}
+		return v.Series.Title
+	}},
+	{name: "series_order", value: func(v niconico.Video) any {
+		if v.Series == nil {
+			return nil
+		}
+		return v.Series.Order
+	}},
+	{name: "is_channel_video", value: func(v niconico.Video) any { return v.IsChannelVideo }},
+	{name: "is_payment_required", value: func(v niconico.Video) any { return v.IsPaymentRequired }},
+	{name: "require_sensitive_masking", value: func(v niconico.Video) any { return v.RequireSensitiveMasking }},
+}
+
+// defaultTableColumns are the csv/tsv columns used without --columns.
+var defaultTableColumns = []string{"id", "title", "registered_at", "view", "comment", "mylist", "like", "duration", "owner_type", "owner_id", "owner_name", "url"}
+
+// selectVideoColumns resolves column names, using defaults when names is empty; nil defaults select nothing.
+func selectVideoColumns(names []string, defaults []string) ([]videoColumn, error) {
+	if len(names) == 0 {
+		names = defaults
+	}
+	columns := make([]videoColumn, 0, len(names))
+	for _, name := range names {
+		index := slices.IndexFunc(videoColumns, func(column videoColumn) bool { return column.name == name })
+		if index < 0 {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns = append(columns, videoColumns[index])
+	}
+	return columns, nil
+}
+
+// columnText formats a column value for csv/tsv cells.
+func columnText(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case int:
+		return strconv.Itoa(value)
+	case bool:
+		return strconv.FormatBool(value)
+	case time.Time:
+		return value.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// tableFormatter writes a header row and one row per video as CSV or TSV.
+type tableFormatter struct {
+	columns []videoColumn
+	comma   rune
+}
+
+func (f *tableFormatter) writeHeader(w io.Writer) error {
+	header := make([]string, 0, len(f.columns))
+	for _, column := range f.columns {
+		header = append(header, column.name)
+	}
+	return f.writeRows(w, [][]string{header})
+}
+
+func (f *tableFormatter) writeVideos(w io.Writer, videos []niconico.Video) error {
+	rows := make([][]string, 0, len(videos))
+	for _, video := range videos {
+		row := make([]string, 0, len(f.columns))
+		for _, column := range f.columns {
+			row = append(row, columnText(column.value(video)))
+		}
+		rows = append(rows, row)
+	}
+	return f.writeRows(w, rows)
+}
+
+func (f *tableFormatter) writeFooter(io.Writer) error { return nil }
+
+// writeRows writes CSV with quoting, or TSV with tabs and line breaks in cells replaced by spaces.
+func (f *tableFormatter) writeRows(w io.Writer, rows [][]string) error {
+	if f.comma == ',' {
+		writer := csv.NewWriter(w)
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	}
+	writer := bufio.NewWriter(w)
+	cleaner := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+	for _, row := range rows {
+		for i, cell := range row {
+			if i > 0 {
+				if err := writer.WriteByte('\t'); err != nil {
+					return err
+				}
+			}
+			if _, err := cleaner.WriteString(writer, cell); err != nil {
+				return err
+			}
+		}
+		if err := writer.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// ndjsonFormatter writes one JSON object per video: the full video object, or only the selected columns.
+type ndjsonFormatter struct {
+	columns []videoColumn
+}
+
+func (f ndjsonFormatter) writeHeader(io.Writer) error { return nil }
+
+func (f ndjsonFormatter) writeVideos(w io.Writer, videos []niconico.Video) error {
+	writer := bufio.NewWriter(w)
+	for _, video := range videos {
+		line, err := f.marshal(video)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func (f ndjsonFormatter) writeFooter(io.Writer) error { return nil }
+
+// marshal encodes video, keeping the --columns order when columns are selected.
+func (f ndjsonFormatter) marshal(video niconico.Video) ([]byte, error) {
+	if len(f.columns) == 0 {
+		return json.Marshal(video)
+	}
+	line := []byte{'{'}
+	for i, column := range f.columns {
+		if i > 0 {
+			line = append(line, ',')
+		}
+		value, err := json.Marshal(column.value(video))
+		if err != nil {
+			return nil, err
+		}
+		line = strconv.AppendQuote(line, column.name)
+		line = append(line, ':')
+		line = append(line, value...)
+	}
+	return append(line, '}'), nil
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+func formatTestVideos() []niconico.Video {
+	return []niconico.Video{
+		{
+			ID:           "sm1",
+			Title:        "title, with \"quotes\"",
+			RegisteredAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Count:        niconico.VideoCount{View: 10, Comment: 2, Mylist: 1, Like: 3},
+			Duration:     90,
+			Owner:        niconico.VideoOwner{Type: "user", ID: "12345", Name: "owner"},
+		},
+		{
+			ID:               "sm2",
+			Title:            "tab\there",
+			ShortDescription: "line\nbreak",
+			Series:           &niconico.VideoSeries{ID: 7, Title: "series", Order: 2},
+		},
+	}
+}
+
+func formatWithConfig(t *testing.T, cfg RootConfig) string {
+	t.Helper()
+	if err := validateOutputFormat(&cfg); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	formatter, err := newVideoFormatter(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := &bytes.Buffer{}
+	if err := writeFormattedOutput(out, formatter, formatTestVideos()); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	return out.String()
+}
+
+func TestCSVFormatterWritesDefaultColumns(t *testing.T) {
+	cfg := newTestRootConfig()
+	cfg.Format = formatCSV
+	want := "id,title,registered_at,view,comment,mylist,like,duration,owner_type,owner_id,owner_name,url\n" +
+		"sm1,\"title, with \"\"quotes\"\"\",2024-01-02T03:04:05Z,10,2,1,3,90,user,12345,owner,https://www.nicovideo.jp/watch/sm1\n" +
+		"sm2,tab\there,0001-01-01T00:00:00Z,0,0,0,0,0,,,,https://www.nicovideo.jp/watch/sm2\n"
+	if got := formatWithConfig(t, cfg); got != want {
+		t.Fatalf("unexpected csv:\n%s", got)
+	}
+}
+
+func TestTSVFormatterWritesSelectedColumns(t *testing.T) {
+	cfg := newTestRootConfig()
+	cfg.Format = formatTSV
+	cfg.Columns = []string{"id", "title", "short_description", "series_order"}
+	want := "id\ttitle\tshort_description\tseries_order\n" +
+		"sm1\ttitle, with \"quotes\"\t\t\n" +
+		"sm2\ttab here\tline break\t2\n"
+	if got := formatWithConfig(t, cfg); got != want {
+		t.Fatalf("unexpected tsv:\n%q", got)
+	}
+}
+
+func TestNDJSONFormatter(t *testing.T) {
+	cfg := newTestRootConfig()
+	cfg.Format = formatNDJSON
+	lines := strings.Split(strings.TrimSuffix(formatWithConfig(t, cfg), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected lines: %v", lines)
+	}
+	var video niconico.Video
+	if err := json.Unmarshal([]byte(lines[1]), &video); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if video.ID != "sm2" || video.Series == nil || video.Series.Order != 2 {
+		t.Fatalf("unexpected video: %+v", video)
+	}
+
+	cfg.Columns = []string{"view", "id", "series_title"}
+	want := `{"view":10,"id":"sm1","series_title":null}` + "\n" + `{"view":0,"id":"sm2","series_title":"series"}` + "\n"
+	if got := formatWithConfig(t, cfg); got != want {
+		t.Fatalf("unexpected ndjson:\n%s", got)
+	}
+}
+
+func TestOutputFormatValidation(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--format", "xml"}, want: "format must be one of atom, batch, csv, html, json, lines, m3u, markdown, ndjson, rss, template, tsv"},
+		{args: []string{"--json", "--format", "csv"}, want: "json cannot be combined with format csv"},
+		{args: []string{"--columns", "id"}, want: "columns requires format csv, tsv, or ndjson"},
+		{args: []string{"--format", "csv", "--columns", "id,plays"}, want: `unknown column "plays"`},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "nicovideo.jp/user/1")
+		_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), args...)
+		if err == nil || err.Error() != tt.want {
+			t.Fatalf("unexpected error for %v: %v", tt.args, err)
+		}
+	}
+}
+
+func newFormatTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"items":[`+
+			`{"essential":{"id":"sm2","title":"second","registeredAt":"2024-01-02T00:00:00Z","count":{"view":20,"comment":10}}},`+
+			`{"essential":{"id":"sm1","title":"first","registeredAt":"2024-01-01T00:00:00Z","count":{"view":10,"comment":10}}}`+
+			`]}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunRootCmdCSVFormatSortsOutput(t *testing.T) {
+	server := newFormatTestServer(t)
+	out, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), "--format", "csv", "--columns", "id,title,view", "nicovideo.jp/user/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); got != "id,title,view\nsm1,first,10\nsm2,second,20\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if !strings.Contains(errOut.String(), "output_count=2") {
+		t.Fatalf("unexpected summary: %q", errOut.String())
+	}
+}
+
+func TestRunRootCmdCSVFormatWritesHeaderWithoutVideos(t *testing.T) {
+	server := newEmptyAPIServer(t)
+	for _, noSort := range []bool{false, true} {
+		cfg := testFetchConfig(server.URL)
+		cfg.NoSortOutput = noSort
+		out, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "--format", "csv", "--columns", "id", "nicovideo.jp/user/1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != "id\n" {
+			t.Fatalf("unexpected output (no-sort=%v): %q", noSort, out.String())
+		}
+	}
+}
+
+func TestRunRootCmdNDJSONStreamsPerTarget(t *testing.T) {
+	server := newFormatTestServer(t)
+	cfg := testFetchConfig(server.URL)
+	cfg.DedupeOutput = true
+	out, errOut, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "--format", "ndjson", "--columns", "id", "nicovideo.jp/user/1", "nicovideo.jp/user/2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Each target's videos arrive in API order; dedupe drops the second target's repeats.
+	if got := out.String(); got != "{\"id\":\"sm2\"}\n{\"id\":\"sm1\"}\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if !strings.Contains(errOut.String(), "fetch_ok=2") || !strings.Contains(errOut.String(), "output_count=2") {
+		t.Fatalf("unexpected summary: %q", errOut.String())
+	}
+}
@@ -10,36 +10,56 @@ func FuzzParseInputTargetNoPanic(f *testing.F) {
 	f.Add("https://www.nicovideo.jp/user/12345/video")
 	f.Add("nicovideo.jp/user/1")
 	f.Add("https://www.nicovideo.jp/mylist/847130")
+	f.Add("https://www.nicovideo.jp/series/12345")
+	f.Add("https://www.nicovideo.jp/user/1/series/12345")
 	f.Add("invalid")
 
+	f.Add("https://www.nicovideo.jp/user/1/mylist/847130")
+	f.Add("https://www.nicovideo.jp/user/1/mylist")
+	f.Add("https://www.nicovideo.jp/user/1/series")
+	f.Add("https://ch.nicovideo.jp/ch2632720")
+	f.Add("ch2632720")
+	f.Add("https://www.nicovideo.jp/tag/VOCALOID")
+	f.Add("nicovideo.jp/search/%E3%82%86%E3%81%A3%E3%81%8F%E3%82%8A")
+
 	f.Fuzz(func(t *testing.T, input string) {
 		target, ok := parseInputTarget(input)
 		if !ok {
-			if userInputPattern.MatchString(input) || mylistInputPattern.MatchString(input) {
-				t.Fatalf("expected parser to accept %q", input)
+			for _, re := range []*regexp.Regexp{userInputPattern, mylistInputPattern, seriesInputPattern, userMylistInputPattern, userSeriesInputPattern, userListsInputPattern, channelInputPattern, bareChannelInputPattern, tagInputPattern, searchInputPattern} {
+				if re.MatchString(input) {
+					t.Fatalf("expected parser to accept %q", input)
+				}
 			}
 			return
 		}
 
 		switch target.Type {
 		case targetTypeUser:
-			match := userInputPattern.FindStringSubmatch(input)
-			if len(match) == 0 {
-				t.Fatalf("expected %q to match the user input pattern", input)
-			}
-			want := submatchByName(match, userInputPattern, "userID")
-			if target.ID != want {
-				t.Fatalf("expected parsed user id %q, got %q for %q", want, target.ID, input)
-			}
+			requireFuzzTargetID(t, input, target, userInputPattern, "userID")
 		case targetTypeMylist:
-			match := mylistInputPattern.FindStringSubmatch(input)
-			if len(match) == 0 {
-				t.Fatalf("expected %q to match the mylist input pattern", input)
+			if userMylistInputPattern.MatchString(input) {
+				requireFuzzTargetID(t, input, target, userMylistInputPattern, "mylistID")
+			} else {
+				requireFuzzTargetID(t, input, target, mylistInputPattern, "mylistID")
+			}
+		case targetTypeSeries:
+			if userSeriesInputPattern.MatchString(input) {
+				requireFuzzTargetID(t, input, target, userSeriesInputPattern, "seriesID")
+			} else {
+				requireFuzzTargetID(t, input, target, seriesInputPattern, "seriesID")
 			}
-			want := submatchByName(match, mylistInputPattern, "mylistID")
-			if target.ID != want {
-				t.Fatalf("expected parsed mylist id %q, got %q for %q", want, target.ID, input)
+		case targetTypeUserMylists, targetTypeUserSeries:
+			requireFuzzTargetID(t, input, target, userListsInputPattern, "userID")
+		case targetTypeChannel:
+			if channelInputPattern.MatchString(input) {
+				requireFuzzTargetID(t, input, target, channelInputPattern, "channelID")
+			} else {
+				requireFuzzTargetID(t, input, target, bareChannelInputPattern, "channelID")
 			}
+		case targetTypeTag:
+			requireFuzzEscapedTargetID(t, input, target, tagInputPattern, "tag")
+		case targetTypeSearch:
+			requireFuzzEscapedTargetID(t, input, target, searchInputPattern, "keyword")
 		default:
 			t.Fatalf("unexpected target type %q", target.Type)
 		}
@@ -49,6 +69,30 @@ func FuzzParseInputTargetNoPanic(f *testing.F) {
 	})
 }
 
+func requireFuzzTargetID(t *testing.T, input string, target inputTarget, re *regexp.Regexp, name string) {
+	t.Helper()
+	match := re.FindStringSubmatch(input)
+	if len(match) == 0 {
+		t.Fatalf("expected %q to match the %s input pattern", input, target.Type)
+	}
+	want := submatchByName(match, re, name)
+	if target.ID != want {
+		t.Fatalf("expected parsed %s id %q, got %q for %q", target.Type, want, target.ID, input)
+	}
+}
+
+func requireFuzzEscapedTargetID(t *testing.T, input string, target inputTarget, re *regexp.Regexp, name string) {
+	t.Helper()
+	match := re.FindStringSubmatch(input)
+	if len(match) == 0 {
+		t.Fatalf("expected %q to match the %s input pattern", input, target.Type)
+	}
+	want := unescapeTargetPath(submatchByName(match, re, name))
+	if target.ID != want {
+		t.Fatalf("expected parsed %s id %q, got %q for %q", target.Type, want, target.ID, input)
+	}
+}
+
 func FuzzSubmatchByNameNoPanic(f *testing.F) {
 	f.Add(uint8(0), "userID", uint8(0))
 	f.Add(uint8(0), "userID", uint8(1))
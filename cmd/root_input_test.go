@@ -6,10 +6,14 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
 )
 
 func TestRunRootCmdNoInputs(t *testing.T) {
@@ -54,8 +58,8 @@ func TestStreamLinesFromFileReturnsCloseError(t *testing.T) {
 	deps.OpenInputFile = func(string) (io.ReadCloser, error) {
 		return closeErrorReader{Reader: strings.NewReader("nicovideo.jp/user/1\n"), err: closeErr}, nil
 	}
-	out := make(chan string, 1)
-	count, err := streamLinesFromFile(context.Background(), "dummy", out, deps)
+	out := make(chan streamInput, 1)
+	count, err := streamLinesFromFile(context.Background(), "dummy", out, deps, nil)
 	if !errors.Is(err, closeErr) {
 		t.Fatalf("expected close error, got %v", err)
 	}
@@ -216,3 +220,136 @@ func TestRunRootCmdInputFileReadErrorCancelsFetches(t *testing.T) {
 		t.Fatal("expected request to be canceled")
 	}
 }
+
+func TestParseInputTarget(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   inputTarget
+		wantOK bool
+	}{
+		{input: "https://www.nicovideo.jp/user/12345/video", want: inputTarget{Type: targetTypeUser, ID: "12345"}, wantOK: true},
+		{input: "nicovideo.jp/mylist/847130", want: inputTarget{Type: targetTypeMylist, ID: "847130"}, wantOK: true},
+		{input: "https://www.nicovideo.jp/series/42", want: inputTarget{Type: targetTypeSeries, ID: "42"}, wantOK: true},
+		{input: "https://www.nicovideo.jp/user/12345/series/42", want: inputTarget{Type: targetTypeSeries, ID: "42"}, wantOK: true},
+		{input: "https://www.nicovideo.jp/user/12345/mylist/847130", want: inputTarget{Type: targetTypeMylist, ID: "847130"}, wantOK: true},
+		{input: "https://www.nicovideo.jp/user/12345/mylist", want: inputTarget{Type: targetTypeUserMylists, ID: "12345"}, wantOK: true},
+		{input: "nicovideo.jp/user/12345/series/", want: inputTarget{Type: targetTypeUserSeries, ID: "12345"}, wantOK: true},
+		{input: "nicovideo.jp/user/12345/series?ref=pc", want: inputTarget{Type: targetTypeUserSeries, ID: "12345"}, wantOK: true},
+		{input: "nicovideo.jp/user/12345/mylists", want: inputTarget{Type: targetTypeUser, ID: "12345"}, wantOK: true},
+		{input: "https://ch.nicovideo.jp/ch2632720", want: inputTarget{Type: targetTypeChannel, ID: "ch2632720"}, wantOK: true},
+		{input: "ch.nicovideo.jp/nicovideo-official/video", want: inputTarget{Type: targetTypeChannel, ID: "nicovideo-official"}, wantOK: true},
+		{input: "ch2632720", want: inputTarget{Type: targetTypeChannel, ID: "ch2632720"}, wantOK: true},
+		{input: "ch2632720/video", wantOK: false},
+		{input: "https://www.nicovideo.jp/tag/%E3%83%9C%E3%82%AB%E3%83%AD?sort=f", want: inputTarget{Type: targetTypeTag, ID: "ボカロ"}, wantOK: true},
+		{input: "nicovideo.jp/search/a%2Fb%20c", want: inputTarget{Type: targetTypeSearch, ID: "a/b c"}, wantOK: true},
+		{input: "nicovideo.jp/tag/%zz", want: inputTarget{Type: targetTypeTag, ID: "%zz"}, wantOK: true},
+		{input: "nicovideo.jp/search/", wantOK: false},
+		{input: "series/42", wantOK: false},
+		{input: "invalid", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := parseInputTarget(tt.input)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("expected (%+v, %v), got (%+v, %v)", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestRunRootCmdUserListsExpansionErrorCountsAsFetchError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	cfg := testFetchConfig(server.URL)
+
+	out, errOut, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/user/1/mylist")
+	if err == nil {
+		t.Fatal("expected expansion error")
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no stdout output, got %q", out.String())
+	}
+	if want := "summary inputs=1 valid=1 invalid=0 fetch_ok=0 fetch_err=1 output_count=0"; !strings.Contains(errOut.String(), want) {
+		t.Errorf("expected summary %q, got %q", want, errOut.String())
+	}
+}
+
+func TestRunRootCmdQueryAndTagTargets(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query := r.URL.Query()
+		mu.Lock()
+		seen[query.Get("q")] = query.Get("targets")
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		id := "sm1"
+		if query.Get("targets") == "tagsExact" {
+			id = "sm2"
+		}
+		_, _ = io.WriteString(w, `{"meta":{"status":200,"totalCount":1},"data":[{"contentId":"`+id+`","startTime":"2024-01-10T00:00:00+09:00","commentCounter":60}]}`)
+	}))
+	t.Cleanup(server.Close)
+	cfg := testFetchConfig(server.URL)
+	cfg.Comment = 50
+
+	out, errOut, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "--query", "ゆっくり 実況", "https://www.nicovideo.jp/tag/%E3%83%9C%E3%82%AB%E3%83%AD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); got != "sm1\nsm2\n" && got != "sm2\nsm1\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if want := "summary inputs=2 valid=2 invalid=0 fetch_ok=2 fetch_err=0 output_count=2"; !strings.Contains(errOut.String(), want) {
+		t.Errorf("expected summary %q, got %q", want, errOut.String())
+	}
+	want := map[string]string{"ゆっくり 実況": "title,description,tags", "ボカロ": "tagsExact"}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("expected search requests %v, got %v", want, seen)
+	}
+}
+
+func TestRunRootCmdTagTargetRejectsUnverifiableExclusions(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"meta":{"status":200,"totalCount":1},"data":[{"contentId":"sm1","startTime":"2024-01-10T00:00:00+09:00","commentCounter":60,"userId":1}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	for _, flag := range []string{"--exclude-paid", "--exclude-sensitive"} {
+		// Tag and search targets given as arguments or --query fail validation.
+		for _, args := range [][]string{
+			{flag, "nicovideo.jp/user/1", "nicovideo.jp/tag/VOCALOID"},
+			{flag, "--no-sort", "nicovideo.jp/search/VOCALOID"},
+			{flag, "--query", "VOCALOID"},
+		} {
+			_, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), args...)
+			if err == nil || !strings.Contains(err.Error(), "search results do not report those flags") {
+				t.Fatalf("%v: unexpected error: %v", args, err)
+			}
+		}
+		// A tag target from an input file is only known while running, so its fetch fails instead.
+		inputFile := writeTestFile(t, "inputs.txt", "nicovideo.jp/tag/VOCALOID\n")
+		out, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), flag, "--input-file", inputFile)
+		if err == nil {
+			t.Fatalf("%s: expected a fetch error", flag)
+		}
+		if out.Len() != 0 {
+			t.Fatalf("%s: expected no unverified videos, got %q", flag, out.String())
+		}
+		if !strings.Contains(err.Error()+errOut.String(), niconico.ErrSearchFlagsUnavailable.Error()) || !strings.Contains(errOut.String(), "fetch_err=1") {
+			t.Fatalf("%s: unexpected stderr: %q", flag, errOut.String())
+		}
+	}
+	if requests.Load() != 0 {
+		t.Fatalf("expected no search requests, got %d", requests.Load())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -17,12 +18,22 @@ import (
 
 // inputStream bundles input channels and total count metadata.
 type inputStream struct {
-	inputs     <-chan string
+	inputs     <-chan streamInput
 	errs       <-chan error
 	totalKnown bool
 	total      int64
 }
 
+// streamInput is one input string and, for expanded targets, the expansion that produced it.
+type streamInput struct {
+	value  string
+	parent *inputTarget
+	err    error
+}
+
+// targetExpander lists the child targets of an expansion target.
+type targetExpander func(ctx context.Context, target inputTarget) ([]inputTarget, error)
+
 // onceReadCloser ensures shared cancellation and cleanup paths close a reader once.
 type onceReadCloser struct {
 	io.Reader
@@ -84,12 +95,18 @@ func defaultIsTerminal(w io.Writer) bool {
 	return false
 }
 
-func streamInputsWithConfig(ctx context.Context, cmd *cobra.Command, args []string, cfg *RootConfig, deps RootDeps) inputStream {
+func streamInputsWithConfig(ctx context.Context, cmd *cobra.Command, args []string, cfg *RootConfig, deps RootDeps, expand targetExpander) inputStream {
 	deps = normalizeRootDeps(deps)
-	out := make(chan string)
+	out := make(chan streamInput)
 	errCh := make(chan error, 1)
 	totalKnown := cfg.InputFilePath == "" && !cfg.ReadStdin
-	total := int64(len(args))
+	total := int64(len(args) + len(cfg.Queries))
+	for _, arg := range args {
+		if target, ok := parseInputTarget(arg); ok && target.isExpansion() {
+			totalKnown = false
+			break
+		}
+	}
 
 	go func() {
 		defer close(out)
@@ -97,14 +114,21 @@ func streamInputsWithConfig(ctx context.Context, cmd *cobra.Command, args []stri
 
 		count := 0
 		for _, arg := range args {
-			if !sendInput(ctx, out, arg) {
+			if !emitInput(ctx, out, arg, expand) {
+				return
+			}
+			count++
+		}
+
+		for _, keyword := range cfg.Queries {
+			if !emitInput(ctx, out, queryInput(keyword), expand) {
 				return
 			}
 			count++
 		}
 
 		if cfg.InputFilePath != "" {
-			n, err := streamLinesFromFile(ctx, cfg.InputFilePath, out, deps)
+			n, err := streamLinesFromFile(ctx, cfg.InputFilePath, out, deps, expand)
 			count += n
 			if err != nil {
 				errCh <- err
@@ -117,7 +141,7 @@ func streamInputsWithConfig(ctx context.Context, cmd *cobra.Command, args []stri
 			if cmd != nil {
 				reader = cmd.InOrStdin()
 			}
-			n, err := streamLines(ctx, reader, out)
+			n, err := streamLines(ctx, reader, out, expand)
 			count += n
 			if err != nil {
 				errCh <- err
@@ -138,8 +162,26 @@ func streamInputsWithConfig(ctx context.Context, cmd *cobra.Command, args []stri
 	}
 }
 
+// emitInput sends input, replacing expansion targets with their child targets when expand is set.
+func emitInput(ctx context.Context, out chan<- streamInput, input string, expand targetExpander) bool {
+	target, ok := parseInputTarget(input)
+	if !ok || !target.isExpansion() || expand == nil {
+		return sendInput(ctx, out, streamInput{value: input})
+	}
+	children, err := expand(ctx, target)
+	for _, child := range children {
+		if !sendInput(ctx, out, streamInput{value: targetInput(child), parent: &target}) {
+			return false
+		}
+	}
+	if err != nil {
+		return sendInput(ctx, out, streamInput{value: input, err: err})
+	}
+	return true
+}
+
 // sendInput sends input unless ctx is canceled.
-func sendInput(ctx context.Context, out chan<- string, input string) bool {
+func sendInput(ctx context.Context, out chan<- streamInput, input streamInput) bool {
 	select {
 	case out <- input:
 		return true
@@ -149,14 +191,14 @@ func sendInput(ctx context.Context, out chan<- string, input string) bool {
 }
 
 // streamLinesFromFile streams trimmed lines from a file into out.
-func streamLinesFromFile(ctx context.Context, path string, out chan<- string, deps RootDeps) (int, error) {
+func streamLinesFromFile(ctx context.Context, path string, out chan<- streamInput, deps RootDeps, expand targetExpander) (int, error) {
 	deps = normalizeRootDeps(deps)
 	openedFile, err := deps.OpenInputFile(path)
 	if err != nil {
 		return 0, err
 	}
 	file := newOnceReadCloser(openedFile)
-	count, err := streamLines(ctx, file, out)
+	count, err := streamLines(ctx, file, out, expand)
 	if closeErr := file.Close(); err == nil && closeErr != nil && ctx.Err() == nil {
 		err = closeErr
 	}
@@ -164,7 +206,7 @@ func streamLinesFromFile(ctx context.Context, path string, out chan<- string, de
 }
 
 // streamLines streams non-empty trimmed lines from a reader into out.
-func streamLines(ctx context.Context, reader io.Reader, out chan<- string) (int, error) {
+func streamLines(ctx context.Context, reader io.Reader, out chan<- streamInput, expand targetExpander) (int, error) {
 	done := make(chan struct{})
 	var closedOnCancel atomic.Bool
 	if closer, ok := reader.(io.Closer); ok {
@@ -180,7 +222,7 @@ func streamLines(ctx context.Context, reader io.Reader, out chan<- string) (int,
 		if line == "" {
 			continue
 		}
-		if !sendInput(ctx, out, line) {
+		if !emitInput(ctx, out, line, expand) {
 			return count, nil
 		}
 		count++
@@ -203,3 +245,35 @@ func closeReaderOnCancel(ctx context.Context, closer io.Closer, done <-chan stru
 	case <-done:
 	}
 }
+
+// writeFileAtomically writes data to a temporary file next to path and renames it into place.
+func writeFileAtomically(path string, data []byte) error {
+	return writeFileAtomicallyWithMode(path, data, 0o600)
+}
+
+// writeFileAtomicallyWithMode is writeFileAtomically with the given permissions instead of 0600.
+func writeFileAtomicallyWithMode(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -3,10 +3,18 @@ package cmd
 import (
 	"sort"
 	"strings"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
 )
 
 const nicoWatchURLPrefix = "https://www.nicovideo.jp/watch/"
 
+// watchURL returns the watch page URL for a video ID.
+func watchURL(id string) string {
+	return nicoWatchURLPrefix + id
+}
+
 // jsonInputs summarizes input counts for JSON output.
 type jsonInputs struct {
 	Total   int64 `json:"total"`
@@ -14,23 +22,46 @@ type jsonInputs struct {
 	Invalid int64 `json:"invalid"`
 }
 
+// targetParent identifies the expansion target a child target was produced by.
+type targetParent struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
 // targetResult captures per-input-target results for JSON output.
 type targetResult struct {
-	Order int      `json:"-"`
-	Type  string   `json:"type"`
-	ID    string   `json:"id"`
-	Items []string `json:"items"`
-	Error string   `json:"error"`
+	Order  int              `json:"-"`
+	Type   string           `json:"type"`
+	ID     string           `json:"id"`
+	Parent *targetParent    `json:"parent,omitempty"`
+	Items  []string         `json:"items"`
+	Videos []niconico.Video `json:"-"`
+	// Listed holds the unfiltered videos --state-file records for the target.
+	Listed []niconico.Video `json:"-"`
+	Error  string           `json:"error"`
+	// Input and the fetch times are reported from schema version 2.
+	Input      string    `json:"-"`
+	StartedAt  time.Time `json:"-"`
+	FinishedAt time.Time `json:"-"`
+}
+
+// targetParentFor converts an expansion target into its JSON parent reference.
+func targetParentFor(parent *inputTarget) *targetParent {
+	if parent == nil {
+		return nil
+	}
+	return &targetParent{Type: parent.Type, ID: parent.ID}
 }
 
 // jsonOutputPayload defines the JSON output schema.
 type jsonOutputPayload struct {
-	Inputs      jsonInputs     `json:"inputs"`
-	Invalid     []string       `json:"invalid"`
-	Targets     []targetResult `json:"targets"`
-	Errors      []string       `json:"errors"`
-	OutputCount int            `json:"output_count"`
-	Items       []string       `json:"items"`
+	Inputs      jsonInputs       `json:"inputs"`
+	Invalid     []string         `json:"invalid"`
+	Targets     []targetResult   `json:"targets"`
+	Errors      []string         `json:"errors"`
+	OutputCount int              `json:"output_count"`
+	Items       []string         `json:"items"`
+	Videos      []niconico.Video `json:"videos"`
 }
 
 // buildJSONOutput assembles the JSON payload from run results.
@@ -42,19 +73,23 @@ func buildJSONOutput(
 	targetResults []targetResult,
 	errorsList []string,
 	outputCount int,
-	outputIDs []string,
+	outputVideos []niconico.Video,
 ) jsonOutputPayload {
-	items := make([]string, 0, len(outputIDs))
-	for _, id := range outputIDs {
-		items = append(items, normalizeOutputID(id))
+	items := make([]string, 0, len(outputVideos))
+	videos := make([]niconico.Video, 0, len(outputVideos))
+	for _, video := range outputVideos {
+		video.ID = normalizeOutputID(video.ID)
+		items = append(items, video.ID)
+		videos = append(videos, video)
 	}
 	targets := make([]targetResult, 0, len(targetResults))
 	for _, target := range targetResults {
 		targets = append(targets, targetResult{
-			Type:  target.Type,
-			ID:    target.ID,
-			Items: normalizeOutputList(target.Items),
-			Error: target.Error,
+			Type:   target.Type,
+			ID:     target.ID,
+			Parent: target.Parent,
+			Items:  normalizeOutputList(target.Items),
+			Error:  target.Error,
 		})
 	}
 	return jsonOutputPayload{
@@ -68,6 +103,7 @@ func buildJSONOutput(
 		Errors:      append([]string{}, errorsList...),
 		OutputCount: outputCount,
 		Items:       items,
+		Videos:      videos,
 	}
 }
 
@@ -87,16 +123,17 @@ func sortTargetResults(results []targetResult) {
 	})
 }
 
-func flattenTargetItemsByInputOrder(results []targetResult) []string {
+// flattenTargetVideosByInputOrder concatenates target videos in input order.
+func flattenTargetVideosByInputOrder(results []targetResult) []niconico.Video {
 	ordered := append([]targetResult{}, results...)
 	sort.Slice(ordered, func(i, j int) bool {
 		return ordered[i].Order < ordered[j].Order
 	})
-	outputIDs := make([]string, 0)
+	outputVideos := make([]niconico.Video, 0)
 	for _, result := range ordered {
-		outputIDs = append(outputIDs, result.Items...)
+		outputVideos = append(outputVideos, result.Videos...)
 	}
-	return outputIDs
+	return outputVideos
 }
 
 // targetIDLess compares target IDs using numeric order when both fit uint64.
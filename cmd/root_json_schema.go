@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+const (
+	// jsonSchemaVersionLegacy is the original payload without schema_version, urls, videos, or per-target parent, input, and timing.
+	jsonSchemaVersionLegacy = 1
+	// jsonSchemaVersionLatest is the default --schema-version.
+	jsonSchemaVersionLatest = 2
+)
+
+// jsonSchemas holds the published JSON Schema document for each payload version.
+//
+//go:embed schemas/output.v*.json
+var jsonSchemas embed.FS
+
+// jsonTargetV1 is a schema version 1 target.
+type jsonTargetV1 struct {
+	Type  string   `json:"type"`
+	ID    string   `json:"id"`
+	Items []string `json:"items"`
+	Error string   `json:"error"`
+}
+
+// jsonOutputPayloadV1 is the schema version 1 payload, laid out as --json wrote it before video metadata was added.
+type jsonOutputPayloadV1 struct {
+	Inputs      jsonInputs     `json:"inputs"`
+	Invalid     []string       `json:"invalid"`
+	Targets     []jsonTargetV1 `json:"targets"`
+	Errors      []string       `json:"errors"`
+	OutputCount int            `json:"output_count"`
+	Items       []string       `json:"items"`
+}
+
+// jsonTargetV2 is a schema version 2 target with its input string and fetch timing.
+type jsonTargetV2 struct {
+	Type       string        `json:"type"`
+	ID         string        `json:"id"`
+	Parent     *targetParent `json:"parent,omitempty"`
+	Input      string        `json:"input"`
+	StartedAt  time.Time     `json:"started_at"`
+	DurationMS int64         `json:"duration_ms"`
+	Items      []string      `json:"items"`
+	Error      string        `json:"error"`
+}
+
+// jsonOutputPayloadV2 is the schema version 2 payload.
+type jsonOutputPayloadV2 struct {
+	SchemaVersion int              `json:"schema_version"`
+	Inputs        jsonInputs       `json:"inputs"`
+	Invalid       []string         `json:"invalid"`
+	Targets       []jsonTargetV2   `json:"targets"`
+	Errors        []string         `json:"errors"`
+	OutputCount   int              `json:"output_count"`
+	Items         []string         `json:"items"`
+	URLs          []string         `json:"urls"`
+	Videos        []niconico.Video `json:"videos"`
+}
+
+// validateSchemaVersion checks --schema-version.
+func validateSchemaVersion(version int) error {
+	if version < jsonSchemaVersionLegacy || version > jsonSchemaVersionLatest {
+		return fmt.Errorf("schema-version must be between %d and %d", jsonSchemaVersionLegacy, jsonSchemaVersionLatest)
+	}
+	return nil
+}
+
+// jsonOutputForVersion converts the legacy payload to the requested schema version.
+func jsonOutputForVersion(version int, payload jsonOutputPayload, targetResults []targetResult) any {
+	if version == jsonSchemaVersionLegacy {
+		targets := make([]jsonTargetV1, 0, len(payload.Targets))
+		for _, target := range payload.Targets {
+			targets = append(targets, jsonTargetV1{Type: target.Type, ID: target.ID, Items: target.Items, Error: target.Error})
+		}
+		return jsonOutputPayloadV1{
+			Inputs:      payload.Inputs,
+			Invalid:     payload.Invalid,
+			Targets:     targets,
+			Errors:      payload.Errors,
+			OutputCount: payload.OutputCount,
+			Items:       payload.Items,
+		}
+	}
+	targets := make([]jsonTargetV2, 0, len(targetResults))
+	for i, target := range targetResults {
+		targets = append(targets, jsonTargetV2{
+			Type:       target.Type,
+			ID:         target.ID,
+			Parent:     target.Parent,
+			Input:      target.Input,
+			StartedAt:  target.StartedAt,
+			DurationMS: target.FinishedAt.Sub(target.StartedAt).Milliseconds(),
+			Items:      payload.Targets[i].Items,
+			Error:      target.Error,
+		})
+	}
+	urls := make([]string, 0, len(payload.Items))
+	for _, id := range payload.Items {
+		urls = append(urls, watchURL(id))
+	}
+	return jsonOutputPayloadV2{
+		SchemaVersion: version,
+		Inputs:        payload.Inputs,
+		Invalid:       payload.Invalid,
+		Targets:       targets,
+		Errors:        payload.Errors,
+		OutputCount:   payload.OutputCount,
+		Items:         payload.Items,
+		URLs:          urls,
+		Videos:        payload.Videos,
+	}
+}
+
+// writeJSONSchema writes the JSON Schema document for a payload version.
+func writeJSONSchema(w io.Writer, version int) error {
+	if err := validateSchemaVersion(version); err != nil {
+		return err
+	}
+	data, err := jsonSchemas.ReadFile(fmt.Sprintf("schemas/output.v%d.json", version))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// checkJSONSchema validates value against the subset of JSON Schema used by the published documents.
+func checkJSONSchema(root, schema map[string]any, value any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		return checkJSONSchema(root, root["$defs"].(map[string]any)[name].(map[string]any), value, path)
+	}
+	if want, ok := schema["const"]; ok && value != want {
+		return fmt.Errorf("%s: got %v, want const %v", path, value, want)
+	}
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: not an object", path)
+		}
+		properties := schema["properties"].(map[string]any)
+		for _, name := range schema["required"].([]any) {
+			if _, ok := object[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing %q", path, name)
+			}
+		}
+		for name, field := range object {
+			propertySchema, ok := properties[name].(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s: unexpected property %q", path, name)
+			}
+			if err := checkJSONSchema(root, propertySchema, field, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: not an array", path)
+		}
+		for i, item := range items {
+			if err := checkJSONSchema(root, schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: not a string", path)
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != float64(int64(number)) {
+			return fmt.Errorf("%s: not an integer", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: not a boolean", path)
+		}
+	}
+	return nil
+}
+
+func loadJSONSchema(t *testing.T, version int) map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	if err := writeJSONSchema(&out, version); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(out.Bytes(), &schema); err != nil {
+		t.Fatalf("schema version %d is not JSON: %v", version, err)
+	}
+	return schema
+}
+
+func TestJSONSchemaFlagPrintsSelectedVersion(t *testing.T) {
+	for _, version := range []int{1, 2} {
+		out, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), "--json-schema", "--schema-version", fmt.Sprint(version))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(out.Bytes(), &schema); err != nil {
+			t.Fatalf("invalid schema: %v", err)
+		}
+		if title := schema["title"].(string); !strings.Contains(title, fmt.Sprintf("schema version %d", version)) {
+			t.Fatalf("unexpected title for version %d: %q", version, title)
+		}
+		_, hasVersion := schema["properties"].(map[string]any)["schema_version"]
+		if hasVersion != (version > 1) {
+			t.Fatalf("unexpected schema_version property for version %d", version)
+		}
+	}
+}
+
+func TestSchemaVersionValidation(t *testing.T) {
+	for _, args := range [][]string{{"--schema-version", "3", "nicovideo.jp/user/1"}, {"--json-schema", "--schema-version", "-1"}} {
+		_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), args...)
+		if err == nil || err.Error() != "schema-version must be between 1 and 2" {
+			t.Fatalf("unexpected error for %v: %v", args, err)
+		}
+	}
+}
+
+func TestRunRootCmdJSONOutputMatchesSchema(t *testing.T) {
+	server := newFormatTestServer(t)
+	for _, version := range []int{1, 2} {
+		var mu sync.Mutex
+		now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		deps := newTestRootDeps()
+		deps.Now = func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			now = now.Add(1500 * time.Millisecond)
+			return now
+		}
+		cfg := testFetchConfig(server.URL)
+		cfg.JSONOutput = true
+		cfg.SchemaVersion = version
+		out, _, err := executeTestRootCommand(t, cfg, deps, "https://www.nicovideo.jp/user/1", "invalid")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+			t.Fatalf("invalid JSON output: %v", err)
+		}
+		schema := loadJSONSchema(t, version)
+		if err := checkJSONSchema(schema, schema, payload, "$"); err != nil {
+			t.Fatalf("version %d payload does not match schema: %v\n%s", version, err, out.String())
+		}
+		if version == 1 {
+			for _, name := range []string{"schema_version", "videos"} {
+				if _, ok := payload[name]; ok {
+					t.Fatalf("version 1 payload has %s: %s", name, out.String())
+				}
+			}
+			continue
+		}
+		var v2 jsonOutputPayloadV2
+		if err := json.Unmarshal(out.Bytes(), &v2); err != nil {
+			t.Fatalf("invalid v2 payload: %v", err)
+		}
+		if v2.SchemaVersion != 2 || !slices.Equal(v2.URLs, []string{"https://www.nicovideo.jp/watch/sm1", "https://www.nicovideo.jp/watch/sm2"}) {
+			t.Fatalf("unexpected v2 payload: %+v", v2)
+		}
+		target := v2.Targets[0]
+		if target.Input != "https://www.nicovideo.jp/user/1" || target.DurationMS != 1500 || target.StartedAt.IsZero() {
+			t.Fatalf("unexpected v2 target: %+v", target)
+		}
+	}
+}
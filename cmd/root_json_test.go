@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -269,3 +270,192 @@ func TestRunRootCmdReturnsJSONOutputWriteError(t *testing.T) {
 		t.Fatalf("expected summary %q, got %q", wantSummary, got)
 	}
 }
+
+func TestRunRootCmdJSONOutputIncludesVideoMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"items":[{"series":{"id":7,"title":"series-7","order":2},"essential":{"id":"sm2","title":"second","registeredAt":"2024-01-10T00:00:00Z","count":{"view":100,"comment":10,"mylist":3,"like":4},"duration":90,"owner":{"ownerType":"user","id":"1","name":"owner"}}},{"essential":{"id":"sm1","title":"first","registeredAt":"2024-01-09T00:00:00Z","count":{"comment":10}}}]}}`)
+	}))
+	t.Cleanup(server.Close)
+	cfg := testFetchConfig(server.URL)
+	cfg.JSONOutput = true
+
+	out, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/user/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var payload struct {
+		Items  []string `json:"items"`
+		Videos []struct {
+			ID           string `json:"id"`
+			Title        string `json:"title"`
+			RegisteredAt string `json:"registered_at"`
+			Count        struct {
+				View    int `json:"view"`
+				Comment int `json:"comment"`
+				Mylist  int `json:"mylist"`
+				Like    int `json:"like"`
+			} `json:"count"`
+			Duration int `json:"duration"`
+			Owner    struct {
+				Type string `json:"type"`
+				Name string `json:"name"`
+			} `json:"owner"`
+			Series *struct {
+				ID    int `json:"id"`
+				Order int `json:"order"`
+			} `json:"series"`
+		} `json:"videos"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to parse JSON output: %v", err)
+	}
+	if strings.Join(payload.Items, ",") != "sm1,sm2" {
+		t.Fatalf("unexpected items: %v", payload.Items)
+	}
+	if len(payload.Videos) != 2 || payload.Videos[0].ID != "sm1" || payload.Videos[1].ID != "sm2" {
+		t.Fatalf("expected videos in items order, got %+v", payload.Videos)
+	}
+	second := payload.Videos[1]
+	if second.Title != "second" || second.RegisteredAt != "2024-01-10T00:00:00Z" || second.Duration != 90 {
+		t.Errorf("unexpected video metadata: %+v", second)
+	}
+	if second.Count.View != 100 || second.Count.Comment != 10 || second.Count.Mylist != 3 || second.Count.Like != 4 {
+		t.Errorf("unexpected video counts: %+v", second.Count)
+	}
+	if second.Owner.Type != "user" || second.Owner.Name != "owner" {
+		t.Errorf("unexpected video owner: %+v", second.Owner)
+	}
+	if second.Series == nil || second.Series.ID != 7 || second.Series.Order != 2 {
+		t.Errorf("unexpected video series: %+v", second.Series)
+	}
+	if payload.Videos[0].Series != nil {
+		t.Errorf("expected no series for first video, got %+v", payload.Videos[0].Series)
+	}
+}
+
+func TestRunRootCmdJSONSeriesTargetKeepsSeriesOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "/series/42") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"detail":{"id":42},"items":[{"meta":{"order":2},"video":{"id":"sm1","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}}},{"meta":{"order":1},"video":{"id":"sm9","registeredAt":"2024-01-09T00:00:00Z","count":{"comment":10}}}]}}`)
+	}))
+	t.Cleanup(server.Close)
+	cfg := testFetchConfig(server.URL)
+	cfg.JSONOutput = true
+	cfg.NoSortOutput = true
+
+	out, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "https://www.nicovideo.jp/user/1/series/42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var payload jsonOutputPayload
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to parse JSON output: %v", err)
+	}
+	if len(payload.Targets) != 1 || payload.Targets[0].Type != targetTypeSeries || payload.Targets[0].ID != "42" {
+		t.Fatalf("unexpected targets: %+v", payload.Targets)
+	}
+	if got := strings.Join(payload.Targets[0].Items, ","); got != "sm9,sm1" {
+		t.Errorf("expected target items in series order, got %v", payload.Targets[0].Items)
+	}
+	if got := strings.Join(payload.Items, ","); got != "sm9,sm1" {
+		t.Errorf("expected --no-sort items in series order, got %v", payload.Items)
+	}
+}
+
+func TestRunRootCmdJSONChannelTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/channels/ch2632720/videos" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[{"id":"so2","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}},{"id":"so1","registeredAt":"2024-01-09T00:00:00Z","count":{"comment":1}}]}}`)
+	}))
+	t.Cleanup(server.Close)
+	cfg := testFetchConfig(server.URL)
+	cfg.JSONOutput = true
+	cfg.Comment = 5
+
+	out, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "https://ch.nicovideo.jp/ch2632720")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var payload jsonOutputPayload
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to parse JSON output: %v", err)
+	}
+	if len(payload.Targets) != 1 || payload.Targets[0].Type != targetTypeChannel || payload.Targets[0].ID != "ch2632720" {
+		t.Fatalf("unexpected targets: %+v", payload.Targets)
+	}
+	if got := strings.Join(payload.Items, ","); got != "so2" {
+		t.Errorf("expected filtered channel items, got %v", payload.Items)
+	}
+}
+
+func TestRunRootCmdJSONUserMylistsExpansionRecordsParent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/users/1/mylists":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"mylists":[{"id":10,"name":"a","isPublic":true},{"id":11,"name":"b","isPublic":true}]}}`)
+		case r.URL.Query().Get("page") != "1":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"mylist":{"items":[]}}}`)
+		case r.URL.Path == "/mylists/10":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"mylist":{"items":[{"video":{"id":"sm10","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}}}]}}}`)
+		case r.URL.Path == "/mylists/11":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"mylist":{"items":[{"video":{"id":"sm11","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}}}]}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	cfg := testFetchConfig(server.URL)
+	cfg.JSONOutput = true
+
+	out, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "https://www.nicovideo.jp/user/1/mylist", "nicovideo.jp/mylist/10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var payload jsonOutputPayload
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to parse JSON output: %v", err)
+	}
+	if payload.Inputs.Total != 3 || payload.Inputs.Valid != 3 {
+		t.Errorf("unexpected inputs: %+v", payload.Inputs)
+	}
+	if len(payload.Targets) != 3 {
+		t.Fatalf("unexpected targets: %+v", payload.Targets)
+	}
+	parents := make([]string, 0, len(payload.Targets))
+	for _, target := range payload.Targets {
+		parent := "-"
+		if target.Parent != nil {
+			parent = target.Parent.Type + ":" + target.Parent.ID
+		}
+		parents = append(parents, target.Type+":"+target.ID+"<"+parent)
+	}
+	sort.Strings(parents)
+	if got := strings.Join(parents, ","); got != "mylist:10<-,mylist:10<user_mylists:1,mylist:11<user_mylists:1" {
+		t.Errorf("unexpected target parents: %s", got)
+	}
+	if got := strings.Join(payload.Items, ","); got != "sm10,sm10,sm11" {
+		t.Errorf("unexpected items: %v", payload.Items)
+	}
+}
@@ -13,9 +13,7 @@ func writeLineOutput(out io.Writer, items []string, withURL bool) error {
 	writer := bufio.NewWriter(out)
 	for _, item := range items {
 		if withURL {
-			if _, err := io.WriteString(writer, nicoWatchURLPrefix); err != nil {
-				return err
-			}
+			item = watchURL(item)
 		}
 		if _, err := io.WriteString(writer, item); err != nil {
 			return err
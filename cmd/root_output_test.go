@@ -10,6 +10,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestWriteLineOutputMatchesExistingFormatting(t *testing.T) {
@@ -234,3 +235,223 @@ func TestRunRootCmdMylistInput(t *testing.T) {
 		t.Errorf("unexpected stdout output: %q", got)
 	}
 }
+
+func TestRunRootCmdSortKeyStopsPagingBeforeDateAfter(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		mu.Lock()
+		requested = append(requested, query.Get("page"))
+		mu.Unlock()
+		if query.Get("sortKey") != "registeredAt" || query.Get("sortOrder") != "desc" {
+			t.Errorf("unexpected sort parameters: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch query.Get("page") {
+		case "1":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[{"essential":{"id":"sm2","registeredAt":"2024-02-01T00:00:00Z","count":{"comment":10}}}]}}`)
+		default:
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[{"essential":{"id":"sm1","registeredAt":"2023-12-01T00:00:00Z","count":{"comment":10}}}]}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	cfg := testFetchConfig(server.URL)
+
+	out, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "--sort-key", "registeredAt", "--dateafter", "20240101", "nicovideo.jp/user/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "sm2\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(requested, ","); got != "1,2" {
+		t.Fatalf("expected paging to stop after page 2, requested pages %s", got)
+	}
+}
+
+func TestRunRootCmdRangeFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"items":[`+
+			`{"essential":{"id":"sm1","registeredAt":"2024-01-01T00:00:00Z","count":{"view":50,"comment":10},"duration":60}},`+
+			`{"essential":{"id":"sm2","registeredAt":"2024-01-02T00:00:00Z","count":{"view":500,"comment":10},"duration":60}},`+
+			`{"essential":{"id":"sm3","registeredAt":"2024-01-03T00:00:00Z","count":{"view":500,"comment":100},"duration":60}},`+
+			`{"essential":{"id":"sm4","registeredAt":"2024-01-04T00:00:00Z","count":{"view":500,"comment":10},"duration":900}}`+
+			`]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	for _, noSort := range []bool{false, true} {
+		args := []string{"--min-views", "100", "--max-comment", "50", "--max-duration", "10m", "nicovideo.jp/user/1"}
+		if noSort {
+			args = append([]string{"--no-sort"}, args...)
+		}
+		out, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), args...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != "sm2\n" {
+			t.Fatalf("unexpected output (no-sort=%v): %q", noSort, out.String())
+		}
+	}
+}
+
+func TestRunRootCmdFilterExpr(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"items":[`+
+			`{"essential":{"id":"sm1","title":"MMD dance","registeredAt":"2024-01-01T00:00:00Z","count":{"view":5000,"comment":10,"like":200}}},`+
+			`{"essential":{"id":"sm2","title":"MMD model","registeredAt":"2024-01-02T00:00:00Z","count":{"view":5000,"comment":10,"like":20}}},`+
+			`{"essential":{"id":"sm3","title":"MMD dance","registeredAt":"2024-01-03T00:00:00Z","count":{"view":5000,"comment":10,"like":200},"isChannelVideo":true}},`+
+			`{"essential":{"id":"sm4","title":"game","registeredAt":"2024-01-04T00:00:00Z","count":{"view":5000,"comment":100,"like":200}}},`+
+			`{"essential":{"id":"sm5","title":"MMD test","registeredAt":"2024-01-05T00:00:00Z","count":{"view":5000,"comment":0,"like":20}}}`+
+			`]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	// The expression decides the comment count itself unless --comment is raised.
+	for expr, want := range map[string]string{"comments == 0": "sm5\n", "comments < 50 && likes < 100": "sm2\nsm5\n"} {
+		out, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), "--filter", expr, "nicovideo.jp/user/1")
+		if err != nil || out.String() != want {
+			t.Fatalf("%s: unexpected output %q, %v", expr, out.String(), err)
+		}
+	}
+	if out, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), "--comment", "5", "--filter", "likes < 100", "nicovideo.jp/user/1"); err != nil || out.String() != "sm2\n" {
+		t.Fatalf("unexpected output with --comment: %q, %v", out.String(), err)
+	}
+
+	expr := `views > 1000 && (comments > 50 || likes > 100) && !channel && title =~ "MMD"`
+	for _, noSort := range []bool{false, true} {
+		args := []string{"--filter", expr, "nicovideo.jp/user/1"}
+		if noSort {
+			args = append([]string{"--no-sort"}, args...)
+		}
+		out, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), args...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != "sm1\n" {
+			t.Fatalf("unexpected output (no-sort=%v): %q", noSort, out.String())
+		}
+	}
+}
+
+func TestRunRootCmdTitleAndDescriptionFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"items":[`+
+			`{"essential":{"id":"sm1","title":"ＭＭＤ ダンス","registeredAt":"2024-01-01T00:00:00Z","count":{"comment":10}}},`+
+			`{"essential":{"id":"sm2","title":"mmd 【生放送】アーカイブ","registeredAt":"2024-01-02T00:00:00Z","count":{"comment":10}}},`+
+			`{"essential":{"id":"sm3","title":"MMD お知らせ","shortDescription":"次回の告知です","registeredAt":"2024-01-03T00:00:00Z","count":{"comment":10}}},`+
+			`{"essential":{"id":"sm4","title":"game","registeredAt":"2024-01-04T00:00:00Z","count":{"comment":10}}}`+
+			`]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	args := []string{
+		"--title-match", "mmd",
+		"--title-exclude", "ｱｰｶｲﾌﾞ",
+		"--desc-exclude", "告知",
+		"--ignore-case",
+		"--normalize-width",
+		"nicovideo.jp/user/1",
+	}
+	out, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), args...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "sm1\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestRunRootCmdExcludesPaidChannelAndSensitive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"items":[`+
+			`{"essential":{"id":"sm1","registeredAt":"2024-01-01T00:00:00Z","count":{"comment":10},"owner":{"ownerType":"user","id":"1"}}},`+
+			`{"essential":{"id":"sm2","registeredAt":"2024-01-02T00:00:00Z","count":{"comment":10},"owner":{"ownerType":"user","id":"1"},"isPaymentRequired":true}},`+
+			`{"essential":{"id":"sm3","registeredAt":"2024-01-03T00:00:00Z","count":{"comment":10},"owner":{"ownerType":"user","id":"1"},"requireSensitiveMasking":true}},`+
+			`{"essential":{"id":"so4","registeredAt":"2024-01-04T00:00:00Z","count":{"comment":10},"owner":{"ownerType":"channel","id":"ch1"},"isChannelVideo":true}}`+
+			`]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--exclude-paid", "--exclude-sensitive"}, want: "sm1\nso4\n"},
+		{args: []string{"--exclude-channel"}, want: "sm1\nsm2\nsm3\n"},
+		{args: []string{"--owner-type", "channel"}, want: "so4\n"},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "nicovideo.jp/user/1")
+		out, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), args...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != tt.want {
+			t.Fatalf("unexpected output for %v: %q", tt.args, out.String())
+		}
+	}
+}
+
+func TestRunRootCmdDateBoundsUseTimezone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"items":[`+
+			`{"essential":{"id":"sm1","registeredAt":"2024-04-30T14:59:59Z","count":{"comment":10}}},`+
+			`{"essential":{"id":"sm2","registeredAt":"2024-04-30T15:00:00Z","count":{"comment":10}}},`+
+			`{"essential":{"id":"sm3","registeredAt":"2024-05-01T14:59:59Z","count":{"comment":10}}},`+
+			`{"essential":{"id":"sm4","registeredAt":"2024-05-01T15:00:00Z","count":{"comment":10}}}`+
+			`]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	deps := newTestRootDeps()
+	deps.Now = func() time.Time { return time.Date(2024, 5, 2, 3, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--dateafter", "20240501", "--datebefore", "2024-05-01"}, want: "sm2\nsm3\n"},
+		{args: []string{"--dateafter", "20240501", "--datebefore", "20240501", "--timezone", "UTC"}, want: "sm3\nsm4\n"},
+		{args: []string{"--dateafter", "2024-04-30T15:00:00Z", "--datebefore", "2024-05-01T14:59:59Z"}, want: "sm2\nsm3\n"},
+		{args: []string{"--dateafter", "24h"}, want: "sm3\nsm4\n"},
+		{args: []string{"--datebefore", "yesterday"}, want: "sm1\nsm2\nsm3\n"},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "nicovideo.jp/user/1")
+		out, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), deps, args...)
+		if err != nil {
+			t.Fatalf("unexpected error for %v: %v", tt.args, err)
+		}
+		if out.String() != tt.want {
+			t.Fatalf("unexpected output for %v: %q", tt.args, out.String())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+const (
+	formatM3U   = "m3u"
+	formatBatch = "batch"
+)
+
+// playlistTextCleaner flattens line breaks so a value stays on one playlist line.
+var playlistTextCleaner = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// pathUnsafeRunes are replaced in output-path hints so they are valid file names on common platforms.
+var pathUnsafeRunes = strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_")
+
+// m3uFormatter writes an extended M3U playlist of watch URLs.
+type m3uFormatter struct{}
+
+func (f m3uFormatter) writeHeader(w io.Writer) error {
+	_, err := io.WriteString(w, "#EXTM3U\n")
+	return err
+}
+
+// writeVideos writes an #EXTINF line with the duration in seconds (-1 when unknown) and "owner - title", then the watch URL.
+func (f m3uFormatter) writeVideos(w io.Writer, videos []niconico.Video) error {
+	writer := bufio.NewWriter(w)
+	for _, video := range videos {
+		duration := video.Duration
+		if duration <= 0 {
+			duration = -1
+		}
+		title := video.Title
+		if title == "" {
+			title = video.ID
+		}
+		if video.Owner.Name != "" {
+			title = video.Owner.Name + " - " + title
+		}
+		entry := "#EXTINF:" + strconv.Itoa(duration) + "," + playlistTextCleaner.Replace(title) + "\n" + watchURL(video.ID) + "\n"
+		if _, err := writer.WriteString(entry); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func (f m3uFormatter) writeFooter(io.Writer) error { return nil }
+
+// batchFormatter writes one watch URL per line for yt-dlp --batch-file and aria2c --input-file.
+type batchFormatter struct {
+	// outputHints adds a "# out=" comment with an owner/title path after each URL; both tools skip "#" lines.
+	outputHints bool
+}
+
+func (f batchFormatter) writeHeader(io.Writer) error { return nil }
+
+func (f batchFormatter) writeVideos(w io.Writer, videos []niconico.Video) error {
+	writer := bufio.NewWriter(w)
+	for _, video := range videos {
+		entry := watchURL(video.ID) + "\n"
+		if f.outputHints {
+			entry += "# out=" + outputPathHint(video) + "\n"
+		}
+		if _, err := writer.WriteString(entry); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func (f batchFormatter) writeFooter(io.Writer) error { return nil }
+
+// outputPathHint returns "<owner>/<title> [<id>]", falling back to the owner ID and video ID when names are empty.
+func outputPathHint(video niconico.Video) string {
+	owner := sanitizePathSegment(video.Owner.Name)
+	if owner == "" {
+		owner = sanitizePathSegment(video.Owner.ID)
+	}
+	if owner == "" {
+		owner = "unknown"
+	}
+	name := video.ID
+	if title := sanitizePathSegment(video.Title); title != "" {
+		name = title + " [" + video.ID + "]"
+	}
+	return owner + "/" + name
+}
+
+// sanitizePathSegment replaces separators, reserved characters, and control characters, and trims spaces and dots.
+func sanitizePathSegment(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, pathUnsafeRunes.Replace(text))
+	return strings.Trim(text, " .")
+}
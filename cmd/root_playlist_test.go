@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+func TestM3UFormatter(t *testing.T) {
+	cfg := newTestRootConfig()
+	cfg.Format = formatM3U
+	want := "#EXTM3U\n" +
+		"#EXTINF:90,owner - title, with \"quotes\"\nhttps://www.nicovideo.jp/watch/sm1\n" +
+		"#EXTINF:-1,tab\there\nhttps://www.nicovideo.jp/watch/sm2\n"
+	if got := formatWithConfig(t, cfg); got != want {
+		t.Fatalf("unexpected m3u:\n%q", got)
+	}
+}
+
+func TestBatchFormatter(t *testing.T) {
+	cfg := newTestRootConfig()
+	cfg.Format = formatBatch
+	if got := formatWithConfig(t, cfg); got != "https://www.nicovideo.jp/watch/sm1\nhttps://www.nicovideo.jp/watch/sm2\n" {
+		t.Fatalf("unexpected batch:\n%q", got)
+	}
+
+	cfg.OutputHints = true
+	want := "https://www.nicovideo.jp/watch/sm1\n# out=owner/title, with _quotes_ [sm1]\n" +
+		"https://www.nicovideo.jp/watch/sm2\n# out=unknown/tab here [sm2]\n"
+	if got := formatWithConfig(t, cfg); got != want {
+		t.Fatalf("unexpected batch with hints:\n%q", got)
+	}
+}
+
+func TestOutputPathHint(t *testing.T) {
+	tests := []struct {
+		video niconico.Video
+		want  string
+	}{
+		{video: niconico.Video{ID: "sm1", Title: "a/b: c?", Owner: niconico.VideoOwner{Name: "..dots.."}}, want: "dots/a_b_ c_ [sm1]"},
+		{video: niconico.Video{ID: "sm2", Title: " . ", Owner: niconico.VideoOwner{ID: "42"}}, want: "42/sm2"},
+		{video: niconico.Video{ID: "sm3", Title: "line\nbreak", Owner: niconico.VideoOwner{Name: "<|>"}}, want: "___/line break [sm3]"},
+	}
+	for _, tt := range tests {
+		if got := outputPathHint(tt.video); got != tt.want {
+			t.Fatalf("outputPathHint(%+v) = %q, want %q", tt.video, got, tt.want)
+		}
+	}
+}
+
+func TestOutputHintsRequireBatchFormat(t *testing.T) {
+	_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), "--format", "m3u", "--output-hints", "nicovideo.jp/user/1")
+	if err == nil || err.Error() != "output-hints requires format batch" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRunRootCmdM3UFormatSortsOutput(t *testing.T) {
+	server := newFormatTestServer(t)
+	out, _, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), "--format", "m3u", "nicovideo.jp/user/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "#EXTM3U\n#EXTINF:-1,first\nhttps://www.nicovideo.jp/watch/sm1\n#EXTINF:-1,second\nhttps://www.nicovideo.jp/watch/sm2\n"
+	if got := out.String(); got != want {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
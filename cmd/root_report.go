@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+const (
+	formatHTML     = "html"
+	formatMarkdown = "markdown"
+
+	reportTitle = "go-nico-list report"
+)
+
+// reportFormats are written from the whole run, like json, instead of through a videoFormatter.
+var reportFormats = []string{formatHTML, formatMarkdown}
+
+// isRunFormat reports whether format is built from the complete run rather than streamed per video.
+func isRunFormat(format string) bool {
+	return format == formatJSON || slices.Contains(reportFormats, format)
+}
+
+// runReport is the data rendered by --format html and --format markdown.
+type runReport struct {
+	Title         string
+	GeneratedAt   time.Time
+	Location      *time.Location
+	Summary       runSummary
+	Filters       []string
+	Targets       []reportTarget
+	InvalidInputs []string
+	Errors        []string
+}
+
+// reportTarget is one target section of a report.
+type reportTarget struct {
+	Type   string
+	ID     string
+	URL    string
+	Parent *targetParent
+	Videos []niconico.Video
+	Error  string
+}
+
+// buildRunReport assembles a report from the sorted target results and the run summary.
+func buildRunReport(cfg *RootConfig, now time.Time, summary runSummary, invalidInputs []string, results []targetResult, errorsList []string) runReport {
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	report := runReport{
+		Title:         reportTitle,
+		GeneratedAt:   now.In(loc),
+		Location:      loc,
+		Summary:       summary,
+		Filters:       reportFilterSummary(cfg),
+		Targets:       make([]reportTarget, 0, len(results)),
+		InvalidInputs: invalidInputs,
+		Errors:        slices.Sorted(slices.Values(errorsList)),
+	}
+	for _, result := range results {
+		videos := slices.Clone(result.Videos)
+		if !cfg.NoSortOutput {
+			niconico.SortVideos(videos)
+		}
+		report.Targets = append(report.Targets, reportTarget{
+			Type:   result.Type,
+			ID:     result.ID,
+			URL:    targetPageURL(inputTarget{Type: result.Type, ID: result.ID}),
+			Parent: result.Parent,
+			Videos: videos,
+			Error:  result.Error,
+		})
+	}
+	return report
+}
+
+// reportFilterSummary lists the filter flags that were set, as "flag: value".
+func reportFilterSummary(cfg *RootConfig) []string {
+	var filters []string
+	add := func(name, value string, set bool) {
+		if set {
+			filters = append(filters, name+": "+value)
+		}
+	}
+	addInt := func(name string, value int) { add(name, columnText(value), value > 0) }
+	addDuration := func(name string, value time.Duration) { add(name, value.String(), value > 0) }
+	addInt("comment", cfg.Comment)
+	add("dateafter", cfg.DateAfter, cfg.DateAfter != "")
+	add("datebefore", cfg.DateBefore, cfg.DateBefore != "")
+	add("timezone", cfg.Timezone, cfg.DateAfter != "" || cfg.DateBefore != "")
+	addInt("min-views", cfg.MinViews)
+	addInt("max-views", cfg.MaxViews)
+	addInt("min-likes", cfg.MinLikes)
+	addInt("max-likes", cfg.MaxLikes)
+	addInt("min-mylists", cfg.MinMylists)
+	addInt("max-mylists", cfg.MaxMylists)
+	addInt("min-comment", cfg.MinComment)
+	addInt("max-comment", cfg.MaxComment)
+	addDuration("min-duration", cfg.MinDuration)
+	addDuration("max-duration", cfg.MaxDuration)
+	add("filter", cfg.FilterExpr, cfg.FilterExpr != "")
+	add("title-match", cfg.TitleMatch, cfg.TitleMatch != "")
+	add("title-exclude", cfg.TitleExclude, cfg.TitleExclude != "")
+	add("desc-match", cfg.DescMatch, cfg.DescMatch != "")
+	add("desc-exclude", cfg.DescExclude, cfg.DescExclude != "")
+	add("ignore-case", "true", cfg.IgnoreCase)
+	add("normalize-width", "true", cfg.NormalizeWidth)
+	add("exclude-channel", "true", cfg.ExcludeChannel)
+	add("exclude-paid", "true", cfg.ExcludePaid)
+	add("exclude-sensitive", "true", cfg.ExcludeSensitive)
+	add("owner-type", cfg.OwnerType, cfg.OwnerType != "")
+	add("dedupe", "true", cfg.DedupeOutput)
+	return filters
+}
+
+// reportFuncs returns the helper functions shared by the report templates.
+func reportFuncs(loc *time.Location) map[string]any {
+	return map[string]any{
+		"watchURL": watchURL,
+		"duration": formatVideoDuration,
+		"date": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.In(loc).Format("2006-01-02 15:04")
+		},
+		"md": markdownCell,
+	}
+}
+
+// markdownEscaper escapes characters with Markdown meaning inside table cells and link text.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", ">", "&gt;",
+	"\r\n", " ", "\n", " ", "\r", " ",
+)
+
+// markdownCell escapes text for a Markdown table cell.
+func markdownCell(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// writeReport renders report as a self-contained HTML page or a Markdown document.
+func writeReport(w io.Writer, format string, report runReport) error {
+	if format == formatMarkdown {
+		tmpl := template.Must(template.New("report").Funcs(reportFuncs(report.Location)).Parse(markdownReportTemplate))
+		return tmpl.Execute(w, report)
+	}
+	tmpl := htmltemplate.Must(htmltemplate.New("report").Funcs(reportFuncs(report.Location)).Parse(htmlReportTemplate))
+	return tmpl.Execute(w, report)
+}
+
+const markdownReportTemplate = `# {{.Title}}
+
+Generated {{date .GeneratedAt}} ({{.Location}})
+
+| inputs | valid | invalid | fetch_ok | fetch_err | output_count |
+| ---: | ---: | ---: | ---: | ---: | ---: |
+| {{.Summary.Inputs}} | {{.Summary.Valid}} | {{.Summary.Invalid}} | {{.Summary.FetchOK}} | {{.Summary.FetchErr}} | {{.Summary.OutputCount}} |
+{{- if .Filters}}
+
+## Filters
+{{range .Filters}}
+- {{md .}}
+{{- end}}
+{{- end}}
+{{- range .Targets}}
+
+## [{{.Type}} {{md .ID}}]({{.URL}})
+{{- if .Parent}}
+
+From {{.Parent.Type}} {{md .Parent.ID}}
+{{- end}}
+{{- if .Error}}
+
+Error: {{md .Error}}
+{{- end}}
+{{- if .Videos}}
+
+| Video | Title | Registered | Views | Comments | Mylists | Likes | Duration |
+| --- | --- | --- | ---: | ---: | ---: | ---: | ---: |
+{{- range .Videos}}
+| [{{.ID}}]({{watchURL .ID}}) | {{md .Title}} | {{date .RegisteredAt}} | {{.Count.View}} | {{.Count.Comment}} | {{.Count.Mylist}} | {{.Count.Like}} | {{duration .Duration}} |
+{{- end}}
+{{- else if not .Error}}
+
+No videos.
+{{- end}}
+{{- end}}
+{{- if .InvalidInputs}}
+
+## Invalid inputs
+{{range .InvalidInputs}}
+- {{md .}}
+{{- end}}
+{{- end}}
+{{- if .Errors}}
+
+## Errors
+{{range .Errors}}
+- {{md .}}
+{{- end}}
+{{- end}}
+`
+
+const htmlReportTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; margin: 0.5rem 0 1.5rem; }
+th, td { border: 1px solid #ccc; padding: 0.3rem 0.6rem; text-align: left; vertical-align: middle; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+img { width: 96px; height: auto; display: block; }
+.error { color: #b00020; }
+.muted { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">Generated {{date .GeneratedAt}} ({{.Location}})</p>
+<table>
+<tr><th>inputs</th><th>valid</th><th>invalid</th><th>fetch_ok</th><th>fetch_err</th><th>output_count</th></tr>
+<tr><td class="num">{{.Summary.Inputs}}</td><td class="num">{{.Summary.Valid}}</td><td class="num">{{.Summary.Invalid}}</td><td class="num">{{.Summary.FetchOK}}</td><td class="num">{{.Summary.FetchErr}}</td><td class="num">{{.Summary.OutputCount}}</td></tr>
+</table>
+{{- if .Filters}}
+<h2>Filters</h2>
+<ul>
+{{- range .Filters}}
+<li><code>{{.}}</code></li>
+{{- end}}
+</ul>
+{{- end}}
+{{- range .Targets}}
+<section>
+<h2><a href="{{.URL}}">{{.Type}} {{.ID}}</a></h2>
+{{- if .Parent}}
+<p class="muted">From {{.Parent.Type}} {{.Parent.ID}}</p>
+{{- end}}
+{{- if .Error}}
+<p class="error">{{.Error}}</p>
+{{- end}}
+{{- if .Videos}}
+<table>
+<tr><th></th><th>Title</th><th>Registered</th><th>Views</th><th>Comments</th><th>Mylists</th><th>Likes</th><th>Duration</th></tr>
+{{- range .Videos}}
+<tr><td>{{if .Thumbnail.URL}}<a href="{{watchURL .ID}}"><img src="{{.Thumbnail.URL}}" alt="" loading="lazy"></a>{{end}}</td><td><a href="{{watchURL .ID}}">{{if .Title}}{{.Title}}{{else}}{{.ID}}{{end}}</a></td><td>{{date .RegisteredAt}}</td><td class="num">{{.Count.View}}</td><td class="num">{{.Count.Comment}}</td><td class="num">{{.Count.Mylist}}</td><td class="num">{{.Count.Like}}</td><td class="num">{{duration .Duration}}</td></tr>
+{{- end}}
+</table>
+{{- else if not .Error}}
+<p class="muted">No videos.</p>
+{{- end}}
+</section>
+{{- end}}
+{{- if .InvalidInputs}}
+<h2>Invalid inputs</h2>
+<ul>
+{{- range .InvalidInputs}}
+<li><code>{{.}}</code></li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Errors}}
+<h2>Errors</h2>
+<ul>
+{{- range .Errors}}
+<li class="error">{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`
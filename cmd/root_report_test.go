@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+func testRunReport(t *testing.T) runReport {
+	t.Helper()
+	cfg := newTestRootConfig()
+	cfg.MinViews = 5
+	cfg.TitleMatch = "a|b"
+	videos := formatTestVideos()
+	videos[0].Thumbnail.URL = "https://example.com/sm1.jpg"
+	results := []targetResult{
+		{Type: targetTypeUser, ID: "1", Videos: []niconico.Video{videos[1], videos[0]}},
+		{Type: targetTypeMylist, ID: "2", Parent: &targetParent{Type: targetTypeUserMylists, ID: "1"}, Videos: []niconico.Video{}},
+		{Type: targetTypeSeries, ID: "3", Error: "series 3: not found", Videos: []niconico.Video{}},
+	}
+	summary := runSummary{Inputs: 4, Valid: 3, Invalid: 1, FetchOK: 2, FetchErr: 1, OutputCount: 2}
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	return buildRunReport(&cfg, now, summary, []string{"bad|input"}, results, []string{"series 3: not found"})
+}
+
+func TestRunSummaryString(t *testing.T) {
+	summary := runSummary{Inputs: 4, Valid: 3, Invalid: 1, FetchOK: 2, FetchErr: 1, OutputCount: 2}
+	if got := summary.String(); got != "summary inputs=4 valid=3 invalid=1 fetch_ok=2 fetch_err=1 output_count=2" {
+		t.Fatalf("unexpected summary: %q", got)
+	}
+}
+
+func TestMarkdownReport(t *testing.T) {
+	var out strings.Builder
+	if err := writeReport(&out, formatMarkdown, testRunReport(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `# go-nico-list report
+
+Generated 2024-02-01 09:00 (Asia/Tokyo)
+
+| inputs | valid | invalid | fetch_ok | fetch_err | output_count |
+| ---: | ---: | ---: | ---: | ---: | ---: |
+| 4 | 3 | 1 | 2 | 1 | 2 |
+
+## Filters
+
+- min-views: 5
+- title-match: a\|b
+
+## [user 1](https://www.nicovideo.jp/user/1/video)
+
+| Video | Title | Registered | Views | Comments | Mylists | Likes | Duration |
+| --- | --- | --- | ---: | ---: | ---: | ---: | ---: |
+| [sm1](https://www.nicovideo.jp/watch/sm1) | title, with "quotes" | 2024-01-02 12:04 | 10 | 2 | 1 | 3 | 1:30 |
+| [sm2](https://www.nicovideo.jp/watch/sm2) | tab	here |  | 0 | 0 | 0 | 0 | 0:00 |
+
+## [mylist 2](https://www.nicovideo.jp/mylist/2)
+
+From user_mylists 1
+
+No videos.
+
+## [series 3](https://www.nicovideo.jp/series/3)
+
+Error: series 3: not found
+
+## Invalid inputs
+
+- bad\|input
+
+## Errors
+
+- series 3: not found
+`
+	if got := out.String(); got != want {
+		t.Fatalf("unexpected markdown:\n%s", got)
+	}
+}
+
+func TestHTMLReportEscapesAndLinksThumbnails(t *testing.T) {
+	report := testRunReport(t)
+	report.Targets[0].Videos[0].Title = "<script>alert(1)</script>"
+	var out strings.Builder
+	if err := writeReport(&out, formatHTML, report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`<a href="https://www.nicovideo.jp/watch/sm1"><img src="https://example.com/sm1.jpg" alt="" loading="lazy"></a>`,
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		`<h2><a href="https://www.nicovideo.jp/series/3">series 3</a></h2>`,
+		`<p class="error">series 3: not found</p>`,
+		"<li><code>min-views: 5</code></li>",
+		`<td class="num">1:30</td>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in html:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<script>") {
+		t.Fatalf("unescaped title in html:\n%s", got)
+	}
+}
+
+func TestRunRootCmdMarkdownReport(t *testing.T) {
+	server := newFormatTestServer(t)
+	out, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), "--format", "markdown", "nicovideo.jp/user/1", "invalid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"| 2 | 1 | 1 | 1 | 0 | 2 |",
+		"## [user 1](https://www.nicovideo.jp/user/1/video)",
+		"| [sm1](https://www.nicovideo.jp/watch/sm1) | first | 2024-01-01 09:00 | 10 | 10 | 0 | 0 | 0:00 |",
+		"## Invalid inputs\n\n- invalid\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in report:\n%s", want, got)
+		}
+	}
+	if !strings.Contains(errOut.String(), "summary inputs=2 valid=1 invalid=1 fetch_ok=1 fetch_err=0 output_count=2") {
+		t.Fatalf("unexpected summary: %q", errOut.String())
+	}
+}
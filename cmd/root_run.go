@@ -5,24 +5,50 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"sync/atomic"
 
-	"github.com/sh4869221b/go-nico-list/internal/niconico"
+	"github.com/sh4869221b/go-nico-list/niconico"
 	"github.com/spf13/cobra"
 )
 
 func runRootCmdWithConfig(cmd *cobra.Command, args []string, cfg *RootConfig, deps RootDeps) (retErr error) {
-	if cfg.NoSortOutput && !cfg.JSONOutput {
+	if cfg.PrintJSONSchema {
+		return writeJSONSchema(outWriterFor(cmd), cfg.SchemaVersion)
+	}
+	if streamsOutput(cfg) {
 		return runRootCmdFastUnordered(cmd, args, cfg, deps)
 	}
 	if err := validateFlagsFor(cfg); err != nil {
 		return err
 	}
-	afterDate, beforeDate, err := parseDateRange(cfg.DateAfter, cfg.DateBefore)
+	if err := validateSearchExclusions(cfg, argTargets(args)); err != nil {
+		return err
+	}
+	format := outputFormatFor(cfg)
+	var formatter videoFormatter
+	if !isRunFormat(format) {
+		var err error
+		if formatter, err = newVideoFormatter(cfg); err != nil {
+			return err
+		}
+	}
+	startedAt := deps.Now()
+	filter, err := newFilter(cfg, startedAt)
+	if err != nil {
+		return err
+	}
+	state, err := loadTargetState(cfg.StateFilePath)
 	if err != nil {
 		return err
 	}
+	var previous previousOutput
+	if cfg.SinceOutput != "" {
+		if previous, err = loadPreviousOutput(cfg.SinceOutput); err != nil {
+			return err
+		}
+	}
 
 	newLogger, cleanup, err := setupLoggerFor(cfg.LogFilePath, deps)
 	if err != nil {
@@ -44,10 +70,11 @@ func runRootCmdWithConfig(cmd *cobra.Command, args []string, cfg *RootConfig, de
 
 	errWriter := errWriterFor(cmd)
 
-	var idList []string
+	var videoList []niconico.Video
 	var mu sync.Mutex
-	stream := streamInputsWithConfig(ctx, cmd, args, cfg, deps)
-	limiter := niconico.NewRateLimiter(cfg.RateLimit, cfg.MinInterval)
+	metrics := newRunMetrics()
+	client := newNiconicoClient(cfg, runLogger, metrics)
+	stream := streamInputsWithConfig(ctx, cmd, args, cfg, deps, newTargetExpander(client))
 	var totalInputs int64
 	var validInputs int64
 	var invalidInputs int64
@@ -99,7 +126,8 @@ func runRootCmdWithConfig(cmd *cobra.Command, args []string, cfg *RootConfig, de
 
 	var inputErr error
 	nextTargetOrder := 0
-	for input := range stream.inputs {
+	for streamed := range stream.inputs {
+		input := streamed.value
 		atomic.AddInt64(&totalInputs, 1)
 		if inputErr == nil {
 			select {
@@ -127,32 +155,55 @@ func runRootCmdWithConfig(cmd *cobra.Command, args []string, cfg *RootConfig, de
 		}
 		targetOrder := nextTargetOrder
 		nextTargetOrder++
+		if streamed.err != nil {
+			atomic.AddInt64(&fetchErrCount, 1)
+			metrics.observeTarget(false)
+			failedAt := deps.Now()
+			mu.Lock()
+			errorsList = append(errorsList, streamed.err.Error())
+			targetResults = append(targetResults, targetResult{
+				Order:      targetOrder,
+				Type:       target.Type,
+				ID:         target.ID,
+				Items:      []string{},
+				Error:      streamed.err.Error(),
+				Input:      input,
+				StartedAt:  failedAt,
+				FinishedAt: failedAt,
+			})
+			mu.Unlock()
+			errCh <- streamed.err
+			addProgress()
+			continue
+		}
+		parent := targetParentFor(streamed.parent)
 		sem <- struct{}{}
 		wg.Add(1)
-		go func(target inputTarget, targetOrder int) {
+		go func(target inputTarget, targetOrder int, input string) {
 			defer wg.Done()
 			defer func() { <-sem }()
 			defer addProgress()
-			var newList []string
-			var err error
-			switch target.Type {
-			case targetTypeUser:
-				newList, err = niconico.GetVideoList(ctx, target.ID, cfg.Comment, afterDate, beforeDate, cfg.BaseURL, cfg.Retries, cfg.HTTPClientTimeout, limiter, cfg.PageConcurrency, runLogger)
-			case targetTypeMylist:
-				newList, err = niconico.GetMylistVideoList(ctx, target.ID, cfg.Comment, afterDate, beforeDate, cfg.BaseURL, cfg.Retries, cfg.HTTPClientTimeout, limiter, cfg.PageConcurrency, runLogger)
-			}
+			startedAt := deps.Now()
+			newList, listed, err := state.fetch(ctx, client, target, filter)
+			finishedAt := deps.Now()
+			metrics.observeTarget(err == nil)
 			if err != nil {
 				atomic.AddInt64(&fetchErrCount, 1)
 				mu.Lock()
 				errorsList = append(errorsList, err.Error())
 				targetResults = append(targetResults, targetResult{
-					Order: targetOrder,
-					Type:  target.Type,
-					ID:    target.ID,
-					Items: newList,
-					Error: err.Error(),
+					Order:      targetOrder,
+					Type:       target.Type,
+					ID:         target.ID,
+					Parent:     parent,
+					Items:      niconico.VideoIDs(newList),
+					Videos:     newList,
+					Error:      err.Error(),
+					Input:      input,
+					StartedAt:  startedAt,
+					FinishedAt: finishedAt,
 				})
-				idList = append(idList, newList...)
+				videoList = append(videoList, newList...)
 				mu.Unlock()
 				errCh <- err
 				return
@@ -160,20 +211,31 @@ func runRootCmdWithConfig(cmd *cobra.Command, args []string, cfg *RootConfig, de
 			atomic.AddInt64(&fetchOKCount, 1)
 			mu.Lock()
 			targetResults = append(targetResults, targetResult{
-				Order: targetOrder,
-				Type:  target.Type,
-				ID:    target.ID,
-				Items: newList,
-				Error: "",
+				Order:      targetOrder,
+				Type:       target.Type,
+				ID:         target.ID,
+				Parent:     parent,
+				Items:      niconico.VideoIDs(newList),
+				Videos:     newList,
+				Listed:     listed,
+				Error:      "",
+				Input:      input,
+				StartedAt:  startedAt,
+				FinishedAt: finishedAt,
 			})
-			idList = append(idList, newList...)
+			videoList = append(videoList, newList...)
 			mu.Unlock()
-		}(target, targetOrder)
+		}(target, targetOrder, input)
 	}
 	wg.Wait()
 	close(errCh)
 	fetchErrRet := <-fetchErrCh
 	sortTargetResults(targetResults)
+	for _, result := range targetResults {
+		if result.Error == "" {
+			state.record(inputTarget{Type: result.Type, ID: result.ID}, result.Listed, deps.Now())
+		}
+	}
 	if inputErr == nil {
 		for err := range inputErrCh {
 			if err != nil {
@@ -183,30 +245,37 @@ func runRootCmdWithConfig(cmd *cobra.Command, args []string, cfg *RootConfig, de
 		}
 	}
 	close(sem)
-	runLogger.Info("video list", "count", len(idList))
-	outputIDs := idList
+	runLogger.Info("video list", "count", len(videoList))
+	outputVideos := videoList
 	if cfg.NoSortOutput {
-		outputIDs = flattenTargetItemsByInputOrder(targetResults)
+		outputVideos = flattenTargetVideosByInputOrder(targetResults)
 	}
-	if cfg.DedupeOutput && len(outputIDs) > 0 {
-		seen := make(map[string]struct{}, len(outputIDs))
-		unique := make([]string, 0, len(outputIDs))
-		for _, id := range outputIDs {
-			if _, ok := seen[id]; ok {
+	if cfg.DedupeOutput && len(outputVideos) > 0 {
+		seen := make(map[string]struct{}, len(outputVideos))
+		unique := make([]niconico.Video, 0, len(outputVideos))
+		for _, video := range outputVideos {
+			if _, ok := seen[video.ID]; ok {
 				continue
 			}
-			seen[id] = struct{}{}
-			unique = append(unique, id)
+			seen[video.ID] = struct{}{}
+			unique = append(unique, video)
 		}
-		outputIDs = unique
+		outputVideos = unique
 	}
-	outputCount := len(outputIDs)
+	outputCount := len(outputVideos)
 	if outputCount > 0 && !cfg.NoSortOutput {
-		niconico.NiconicoSort(outputIDs)
+		niconico.SortVideos(outputVideos)
+	}
+	summary := runSummary{
+		Inputs:      atomic.LoadInt64(&totalInputs),
+		Valid:       atomic.LoadInt64(&validInputs),
+		Invalid:     atomic.LoadInt64(&invalidInputs),
+		FetchOK:     atomic.LoadInt64(&fetchOKCount),
+		FetchErr:    atomic.LoadInt64(&fetchErrCount),
+		OutputCount: outputCount,
 	}
 	out := outWriterFor(cmd)
-	var outputErr error
-	if cfg.JSONOutput {
+	jsonOutput := func() any {
 		jsonPayload := buildJSONOutput(
 			totalInputs,
 			validInputs,
@@ -215,37 +284,58 @@ func runRootCmdWithConfig(cmd *cobra.Command, args []string, cfg *RootConfig, de
 			targetResults,
 			errorsList,
 			outputCount,
-			outputIDs,
+			outputVideos,
 		)
-		enc := json.NewEncoder(out)
-		if err := enc.Encode(jsonPayload); err != nil {
-			outputErr = err
+		return jsonOutputForVersion(cfg.SchemaVersion, jsonPayload, targetResults)
+	}
+	var outputErr error
+	if cfg.SinceOutput != "" && ctx.Err() != nil {
+		// Fetches cut short by the cancellation look like successes with fewer items, so they would diff as removals.
+		outputErr = inputErr
+		if outputErr == nil {
+			outputErr = fmt.Errorf("since-output: run interrupted: %w", ctx.Err())
+		}
+	} else if cfg.SinceOutput != "" {
+		outputErr = writeDiffOutput(out, cfg, diffTargetResults(previous, targetResults))
+		if outputErr == nil && cfg.SaveOutput != "" {
+			if summary.FetchErr > 0 {
+				// Keeping the previous file means the next diff still covers the failed targets' changes.
+				runLogger.Warn("save-output not updated after fetch errors", "path", cfg.SaveOutput)
+			} else {
+				outputErr = writeSavedOutput(cfg.SaveOutput, jsonOutput())
+			}
 		}
-	} else if outputCount > 0 {
-		if err := writeLineOutput(out, outputIDs, cfg.URL); err != nil {
+	} else if format == formatJSON {
+		enc := json.NewEncoder(out)
+		if err := enc.Encode(jsonOutput()); err != nil {
 			outputErr = err
 		}
+	} else if slices.Contains(reportFormats, format) {
+		report := buildRunReport(cfg, startedAt, summary, invalidInputsList, targetResults, errorsList)
+		outputErr = writeReport(out, format, report)
+	} else if cfg.FeedDir != "" {
+		outputErr = writeTargetFeeds(cfg.FeedDir, cfg, targetResults)
+	} else if err := writeFormattedOutput(out, formatter, outputVideos); err != nil {
+		outputErr = err
 	}
 	if shouldShowProgressWithConfig(errWriter, cfg, deps) {
 		if _, err := fmt.Fprintln(errWriter); err != nil {
 			return err
 		}
 	}
-	if _, err := fmt.Fprintf(
-		errWriter,
-		"summary inputs=%d valid=%d invalid=%d fetch_ok=%d fetch_err=%d output_count=%d\n",
-		atomic.LoadInt64(&totalInputs),
-		atomic.LoadInt64(&validInputs),
-		atomic.LoadInt64(&invalidInputs),
-		atomic.LoadInt64(&fetchOKCount),
-		atomic.LoadInt64(&fetchErrCount),
-		outputCount,
-	); err != nil {
+	if _, err := fmt.Fprintln(errWriter, summary); err != nil {
+		return err
+	}
+	metrics.observeItems(outputCount)
+	if err := writeMetricsFile(cfg.MetricsFile, metrics, deps.Now()); err != nil {
 		return err
 	}
 	if outputErr != nil {
 		return outputErr
 	}
+	if err := state.save(); err != nil {
+		return err
+	}
 	if inputErr != nil {
 		return inputErr
 	}
@@ -2,15 +2,39 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"slices"
 	"time"
 
+	"github.com/sh4869221b/go-nico-list/niconico"
 	"github.com/spf13/cobra"
 )
 
+// runSummary holds the counts printed on the stderr summary line and shown in reports.
+type runSummary struct {
+	Inputs      int64
+	Valid       int64
+	Invalid     int64
+	FetchOK     int64
+	FetchErr    int64
+	OutputCount int
+}
+
+// String formats the stderr summary line without a trailing newline.
+func (s runSummary) String() string {
+	return fmt.Sprintf(
+		"summary inputs=%d valid=%d invalid=%d fetch_ok=%d fetch_err=%d output_count=%d",
+		s.Inputs, s.Valid, s.Invalid, s.FetchOK, s.FetchErr, s.OutputCount,
+	)
+}
+
 func validateFlagsFor(cfg *RootConfig) error {
+	if err := validateSchemaVersion(cfg.SchemaVersion); err != nil {
+		return err
+	}
 	if cfg.Concurrency < 1 {
 		return errors.New("concurrency must be at least 1")
 	}
@@ -29,24 +53,154 @@ func validateFlagsFor(cfg *RootConfig) error {
 	if cfg.MinInterval < 0 {
 		return errors.New("min-interval must be at least 0")
 	}
+	if cfg.CacheTTL < 0 {
+		return errors.New("cache-ttl must be at least 0")
+	}
+	if cfg.SortKey != "" && !slices.Contains(niconico.SortKeys, niconico.SortKey(cfg.SortKey)) {
+		return errors.New("sort-key must be one of registeredAt, addedAt, viewCount, commentCount, mylistCount, likeCount, lastCommentTime, duration")
+	}
+	if cfg.SortOrder != "" && cfg.SortOrder != string(niconico.SortOrderAsc) && cfg.SortOrder != string(niconico.SortOrderDesc) {
+		return errors.New("sort-order must be asc or desc")
+	}
+	if cfg.SortOrder != "" && cfg.SortKey == "" {
+		return errors.New("sort-order requires sort-key")
+	}
+	for _, bounds := range []struct {
+		name     string
+		min, max int64
+	}{
+		{name: "views", min: int64(cfg.MinViews), max: int64(cfg.MaxViews)},
+		{name: "likes", min: int64(cfg.MinLikes), max: int64(cfg.MaxLikes)},
+		{name: "mylists", min: int64(cfg.MinMylists), max: int64(cfg.MaxMylists)},
+		{name: "comment", min: int64(cfg.MinComment), max: int64(cfg.MaxComment)},
+		{name: "duration", min: int64(cfg.MinDuration), max: int64(cfg.MaxDuration)},
+	} {
+		if bounds.min < 0 || bounds.max < 0 {
+			return fmt.Errorf("min-%s and max-%s must be at least 0", bounds.name, bounds.name)
+		}
+		if bounds.min > 0 && bounds.max > 0 && bounds.min > bounds.max {
+			return fmt.Errorf("min-%s must be less than or equal to max-%s", bounds.name, bounds.name)
+		}
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+	if cfg.OwnerType != "" && cfg.OwnerType != niconico.OwnerTypeUser && cfg.OwnerType != niconico.OwnerTypeChannel {
+		return errors.New("owner-type must be user or channel")
+	}
+	if cfg.FilterExpr != "" {
+		if _, err := niconico.ParseExprInLocation(cfg.FilterExpr, loc); err != nil {
+			return fmt.Errorf("filter %w", err)
+		}
+	}
+	if err := applyTextFilters(&niconico.Filter{}, cfg); err != nil {
+		return err
+	}
+	return validateOutputFormat(cfg)
+}
+
+// applyTextFilters compiles the title and description regex flags into filter.
+func applyTextFilters(filter *niconico.Filter, cfg *RootConfig) error {
+	options := niconico.TextMatchOptions{IgnoreCase: cfg.IgnoreCase, NormalizeWidth: cfg.NormalizeWidth}
+	for _, text := range []struct {
+		name    string
+		pattern string
+		dst     **niconico.TextPattern
+	}{
+		{name: "title-match", pattern: cfg.TitleMatch, dst: &filter.TitleMatch},
+		{name: "title-exclude", pattern: cfg.TitleExclude, dst: &filter.TitleExclude},
+		{name: "desc-match", pattern: cfg.DescMatch, dst: &filter.DescriptionMatch},
+		{name: "desc-exclude", pattern: cfg.DescExclude, dst: &filter.DescriptionExclude},
+	} {
+		if text.pattern == "" {
+			continue
+		}
+		compiled, err := niconico.CompileTextPattern(text.pattern, options)
+		if err != nil {
+			return fmt.Errorf("%s: %w", text.name, err)
+		}
+		*text.dst = compiled
+	}
+	return nil
+}
+
+// validateSearchExclusions rejects --exclude-paid and --exclude-sensitive for the tag and search targets known before
+// fetching: --query keywords and targets. Targets read from --input-file or --stdin fail their fetch instead.
+func validateSearchExclusions(cfg *RootConfig, targets []inputTarget) error {
+	if !cfg.ExcludePaid && !cfg.ExcludeSensitive {
+		return nil
+	}
+	for _, keyword := range cfg.Queries {
+		targets = append(targets, inputTarget{Type: targetTypeSearch, ID: keyword})
+	}
+	for _, target := range targets {
+		if target.Type == targetTypeTag || target.Type == targetTypeSearch {
+			return fmt.Errorf("exclude-paid and exclude-sensitive cannot be used with %s target %q: search results do not report those flags", target.Type, target.ID)
+		}
+	}
 	return nil
 }
 
-// parseDateRange parses date strings into UTC time values.
-func parseDateRange(after, before string) (time.Time, time.Time, error) {
-	const dateFormat = "20060102"
-	parsedAfter, err := time.Parse(dateFormat, after)
+// argTargets returns the targets of the command-line arguments that parse as one.
+func argTargets(args []string) []inputTarget {
+	targets := make([]inputTarget, 0, len(args))
+	for _, arg := range args {
+		if target, ok := parseInputTarget(arg); ok {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// newFilter builds the fetch filter from flag values, parsing the date range relative to now.
+func newFilter(cfg *RootConfig, now time.Time) (niconico.Filter, error) {
+	loc, err := time.LoadLocation(cfg.Timezone)
 	if err != nil {
-		return time.Time{}, time.Time{}, errors.New("dateafter format error")
+		return niconico.Filter{}, fmt.Errorf("timezone: %w", err)
 	}
-	parsedBefore, err := time.Parse(dateFormat, before)
+	dates, err := parseDateRange(cfg.DateAfter, cfg.DateBefore, now, loc)
 	if err != nil {
-		return time.Time{}, time.Time{}, errors.New("datebefore format error")
+		return niconico.Filter{}, err
+	}
+	commentCount := cfg.Comment
+	if cfg.FilterExpr != "" && commentCount == 0 {
+		// --comment 0 is the default; left there, it would keep --filter from selecting videos without comments.
+		commentCount = -1
+	}
+	filter := niconico.Filter{
+		CommentCount: commentCount,
+		After:        dates.after,
+		Before:       dates.beforeDay,
+		Until:        dates.until,
+		Location:     loc,
+		MinViews:     cfg.MinViews,
+		MaxViews:     cfg.MaxViews,
+		MinLikes:     cfg.MinLikes,
+		MaxLikes:     cfg.MaxLikes,
+		MinMylists:   cfg.MinMylists,
+		MaxMylists:   cfg.MaxMylists,
+		MinComments:  cfg.MinComment,
+		MaxComments:  cfg.MaxComment,
+		MinDuration:  cfg.MinDuration,
+		MaxDuration:  cfg.MaxDuration,
+
+		ExcludeChannel:   cfg.ExcludeChannel,
+		ExcludePaid:      cfg.ExcludePaid,
+		ExcludeSensitive: cfg.ExcludeSensitive,
+		OwnerType:        cfg.OwnerType,
+	}
+	if cfg.FilterExpr != "" {
+		expr, err := niconico.ParseExprInLocation(cfg.FilterExpr, loc)
+		if err != nil {
+			return niconico.Filter{}, fmt.Errorf("filter %w", err)
+		}
+		filter.Expr = expr
 	}
-	if parsedAfter.After(parsedBefore) {
-		return time.Time{}, time.Time{}, errors.New("dateafter must be on or before datebefore")
+	if err := applyTextFilters(&filter, cfg); err != nil {
+		return niconico.Filter{}, err
 	}
-	return parsedAfter, parsedBefore, nil
+	return filter, nil
 }
 
 func setupLoggerFor(path string, deps RootDeps) (*slog.Logger, func() error, error) {
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+const stateFileVersion = 1
+
+// targetCheckpoint records the newest video seen for a target in a successful run.
+type targetCheckpoint struct {
+	Type               string    `json:"type"`
+	ID                 string    `json:"id"`
+	NewestID           string    `json:"newest_id"`
+	NewestRegisteredAt time.Time `json:"newest_registered_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// stateFilePayload is the on-disk layout of --state-file.
+type stateFilePayload struct {
+	Version int                         `json:"version"`
+	Targets map[string]targetCheckpoint `json:"targets"`
+}
+
+// targetState holds per-target checkpoints for incremental runs; a state without a path is disabled.
+type targetState struct {
+	path    string
+	mu      sync.Mutex
+	targets map[string]targetCheckpoint
+}
+
+// loadTargetState reads the state file at path, treating a missing file as empty; an empty path returns a disabled state.
+func loadTargetState(path string) (*targetState, error) {
+	if path == "" {
+		return &targetState{}, nil
+	}
+	state := &targetState{path: path, targets: make(map[string]targetCheckpoint)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var payload stateFilePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("state-file %s: %w", path, err)
+	}
+	if payload.Version != stateFileVersion {
+		return nil, fmt.Errorf("state-file %s: unsupported version %d", path, payload.Version)
+	}
+	for key, checkpoint := range payload.Targets {
+		state.targets[key] = checkpoint
+	}
+	return state, nil
+}
+
+// targetStateKey returns the state file key for a target.
+func targetStateKey(target inputTarget) string {
+	return target.Type + "/" + target.ID
+}
+
+// checkpointed reports whether target lists its videos by registration time, so a checkpoint can skip the known ones.
+// Mylists are ordered by when videos were added, so an old video added later would fall behind the checkpoint.
+func checkpointed(target inputTarget) bool {
+	switch target.Type {
+	case targetTypeUser, targetTypeChannel, targetTypeSeries:
+		return true
+	default:
+		return false
+	}
+}
+
+// fetch fetches target's videos through the state and also returns the videos to record. A checkpointed target is listed
+// with the date bounds and the checkpoint only, so recording advances its checkpoint to the newest listed video even when
+// filter drops that video; filter is applied afterwards. Other targets return no videos to record.
+func (s *targetState) fetch(ctx context.Context, client *niconico.Client, target inputTarget, filter niconico.Filter) ([]niconico.Video, []niconico.Video, error) {
+	if s.path == "" || !checkpointed(target) {
+		videos, err := fetchTargetList(ctx, client, target, filter)
+		return videos, nil, err
+	}
+	listFilter := s.filterFor(target, niconico.Filter{
+		// -1 is below every comment count, so no video is dropped for its comments.
+		CommentCount: -1,
+		After:        filter.After,
+		Before:       filter.Before,
+		Until:        filter.Until,
+		Location:     filter.Location,
+	})
+	listed, err := fetchTargetList(ctx, client, target, listFilter)
+	videos := make([]niconico.Video, 0, len(listed))
+	for _, video := range listed {
+		if filter.Match(video) {
+			videos = append(videos, video)
+		}
+	}
+	return videos, listed, err
+}
+
+// filterFor narrows filter to videos registered after the target's checkpoint.
+func (s *targetState) filterFor(target inputTarget, filter niconico.Filter) niconico.Filter {
+	s.mu.Lock()
+	checkpoint, ok := s.targets[targetStateKey(target)]
+	s.mu.Unlock()
+	if !ok || checkpoint.NewestRegisteredAt.IsZero() {
+		return filter
+	}
+	// Registration times have one-second precision, so the next second excludes the checkpoint video itself.
+	since := checkpoint.NewestRegisteredAt.Add(time.Second)
+	if since.After(filter.After) {
+		filter.After = since
+	}
+	return filter
+}
+
+// record advances the target's checkpoint to the newest of videos; callers must only record successful fetches.
+func (s *targetState) record(target inputTarget, videos []niconico.Video, now time.Time) {
+	if s.path == "" || len(videos) == 0 {
+		return
+	}
+	newest := videos[0]
+	for _, video := range videos[1:] {
+		if video.RegisteredAt.After(newest.RegisteredAt) {
+			newest = video
+		}
+	}
+	key := targetStateKey(target)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.targets[key]; ok && !newest.RegisteredAt.After(current.NewestRegisteredAt) {
+		return
+	}
+	s.targets[key] = targetCheckpoint{
+		Type:               target.Type,
+		ID:                 target.ID,
+		NewestID:           newest.ID,
+		NewestRegisteredAt: newest.RegisteredAt,
+		UpdatedAt:          now.UTC(),
+	}
+}
+
+// save atomically replaces the state file with the current checkpoints.
+func (s *targetState) save() error {
+	if s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	data, err := json.MarshalIndent(stateFilePayload{Version: stateFileVersion, Targets: s.targets}, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(s.path, append(data, '\n'))
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newStateTestServer serves newest-first user video pages from videos, keyed by user ID, and fails user IDs in failing.
+// Entries are "<id>@<date>", optionally followed by "#<comments>" (default 10).
+func newStateTestServer(t *testing.T, mu *sync.Mutex, videos map[string][]string, failing map[string]bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/users/"), "/videos")
+		if r.URL.Query().Has("sortKey") {
+			t.Errorf("expected the API's default newest-first order, got %s", r.URL.RawQuery)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if failing[userID] {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[]}}`)
+			return
+		}
+		items := make([]string, 0, len(videos[userID]))
+		for _, entry := range videos[userID] {
+			id, date, _ := strings.Cut(entry, "@")
+			date, comments, ok := strings.Cut(date, "#")
+			if !ok {
+				comments = "10"
+			}
+			items = append(items, fmt.Sprintf(`{"essential":{"id":"%s","registeredAt":"%sT00:00:00Z","count":{"comment":%s}}}`, id, date, comments))
+		}
+		_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[`+strings.Join(items, ",")+`]}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func readStateFile(t *testing.T, path string) stateFilePayload {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read state file: %v", err)
+	}
+	var payload stateFilePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("failed to parse state file: %v", err)
+	}
+	return payload
+}
+
+func TestRunRootCmdStateFileOutputsOnlyNewVideos(t *testing.T) {
+	for _, noSort := range []bool{false, true} {
+		t.Run(fmt.Sprintf("no-sort=%v", noSort), func(t *testing.T) {
+			var mu sync.Mutex
+			videos := map[string][]string{"1": {"sm2@2024-01-02", "sm1@2024-01-01"}}
+			server := newStateTestServer(t, &mu, videos, nil)
+			cfg := testFetchConfig(server.URL)
+			cfg.StateFilePath = filepath.Join(t.TempDir(), "state.json")
+			cfg.NoSortOutput = noSort
+
+			out, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/user/1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Fields(out.String()); len(got) != 2 {
+				t.Fatalf("expected both videos on the first run, got %v", got)
+			}
+			checkpoint := readStateFile(t, cfg.StateFilePath).Targets["user/1"]
+			if checkpoint.NewestID != "sm2" || !checkpoint.NewestRegisteredAt.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("unexpected checkpoint: %+v", checkpoint)
+			}
+
+			mu.Lock()
+			videos["1"] = append([]string{"sm3@2024-01-03"}, videos["1"]...)
+			mu.Unlock()
+			out, _, err = executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/user/1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != "sm3\n" {
+				t.Fatalf("expected only the new video, got %q", out.String())
+			}
+			if checkpoint := readStateFile(t, cfg.StateFilePath).Targets["user/1"]; checkpoint.NewestID != "sm3" {
+				t.Fatalf("expected checkpoint to advance to sm3, got %+v", checkpoint)
+			}
+
+			out, _, err = executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/user/1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Len() != 0 {
+				t.Fatalf("expected no output without new videos, got %q", out.String())
+			}
+			if checkpoint := readStateFile(t, cfg.StateFilePath).Targets["user/1"]; checkpoint.NewestID != "sm3" {
+				t.Fatalf("expected checkpoint to stay at sm3, got %+v", checkpoint)
+			}
+		})
+	}
+}
+
+func TestRunRootCmdStateFileKeepsFailedTargetCheckpoint(t *testing.T) {
+	var mu sync.Mutex
+	videos := map[string][]string{
+		"1": {"sm1@2024-01-01"},
+		"2": {"sm2@2024-01-01"},
+	}
+	failing := map[string]bool{}
+	server := newStateTestServer(t, &mu, videos, failing)
+	cfg := testFetchConfig(server.URL)
+	cfg.StateFilePath = filepath.Join(t.TempDir(), "state.json")
+	cfg.JSONOutput = true
+
+	if _, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/user/1", "nicovideo.jp/user/2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	videos["1"] = append([]string{"sm10@2024-02-01"}, videos["1"]...)
+	videos["2"] = append([]string{"sm20@2024-02-01"}, videos["2"]...)
+	failing["2"] = true
+	mu.Unlock()
+	cfg.BestEffort = true
+	if _, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/user/1", "nicovideo.jp/user/2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	targets := readStateFile(t, cfg.StateFilePath).Targets
+	if targets["user/1"].NewestID != "sm10" {
+		t.Errorf("expected successful target to advance, got %+v", targets["user/1"])
+	}
+	if targets["user/2"].NewestID != "sm2" {
+		t.Errorf("expected failed target to keep its checkpoint, got %+v", targets["user/2"])
+	}
+}
+
+func TestRunRootCmdStateFileCheckpointsFilteredOutVideos(t *testing.T) {
+	var mu sync.Mutex
+	videos := map[string][]string{"1": {"sm2@2024-01-02#0", "sm1@2024-01-01"}}
+	server := newStateTestServer(t, &mu, videos, nil)
+	cfg := testFetchConfig(server.URL)
+	cfg.StateFilePath = filepath.Join(t.TempDir(), "state.json")
+	deps := newTestRootDeps()
+	deps.Now = fixedNow
+
+	out, _, err := executeTestRootCommand(t, cfg, deps, "nicovideo.jp/user/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "sm1\n" {
+		t.Fatalf("expected the comment filter to drop sm2, got %q", out.String())
+	}
+	// The checkpoint follows the newest listed video, even though the filter dropped it.
+	checkpoint := readStateFile(t, cfg.StateFilePath).Targets["user/1"]
+	if checkpoint.NewestID != "sm2" || !checkpoint.UpdatedAt.Equal(fixedNow()) {
+		t.Fatalf("unexpected checkpoint: %+v", checkpoint)
+	}
+}
+
+func TestRunRootCmdStateFileSkipsMylists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("sortKey") {
+			t.Errorf("expected the API's default order, got %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"mylist":{"items":[]}}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"mylist":{"items":[{"video":{"id":"sm10","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}}}]}}}`)
+	}))
+	t.Cleanup(server.Close)
+	cfg := testFetchConfig(server.URL)
+	cfg.StateFilePath = filepath.Join(t.TempDir(), "state.json")
+
+	// Mylists have no checkpoint, so every run lists them in full.
+	for range 2 {
+		out, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/mylist/10")
+		if err != nil || out.String() != "sm10\n" {
+			t.Fatalf("unexpected output: %q, %v", out.String(), err)
+		}
+	}
+	if targets := readStateFile(t, cfg.StateFilePath).Targets; len(targets) != 0 {
+		t.Fatalf("expected no mylist checkpoint, got %+v", targets)
+	}
+}
+
+func TestRunRootCmdStateFileRejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+	cfg := testFetchConfig(newEmptyAPIServer(t).URL)
+	cfg.StateFilePath = path
+
+	_, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/user/1")
+	if err == nil || !strings.Contains(err.Error(), "state-file") {
+		t.Fatalf("expected state file error, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+const formatTemplate = "template"
+
+// templateEscapes expands backslash escapes in --template text outside of actions.
+var templateEscapes = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r")
+
+// templateFormatter renders each video through a text/template, one entry per video.
+type templateFormatter struct {
+	tmpl *template.Template
+}
+
+// newTemplateFormatter parses --template or --template-file with the template helper functions.
+func newTemplateFormatter(cfg *RootConfig) (videoFormatter, error) {
+	if cfg.Template != "" && cfg.TemplateFile != "" {
+		return nil, errors.New("template and template-file cannot be combined")
+	}
+	name := "template"
+	source := unescapeTemplateText(cfg.Template)
+	if cfg.TemplateFile != "" {
+		data, err := os.ReadFile(cfg.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("template-file: %w", err)
+		}
+		name = filepath.Base(cfg.TemplateFile)
+		source = string(data)
+	}
+	if source == "" {
+		return nil, errors.New("format template requires template or template-file")
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("timezone: %w", err)
+	}
+	// Parse errors already read "template: <name>:<line>: ...".
+	tmpl, err := template.New(name).Funcs(templateFuncs(loc)).Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return templateFormatter{tmpl: tmpl}, nil
+}
+
+// templateFuncs returns the helper functions available to --template.
+func templateFuncs(loc *time.Location) template.FuncMap {
+	return template.FuncMap{
+		"watchURL": watchURL,
+		"duration": formatVideoDuration,
+		"json": func(value any) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+		"local": func(t time.Time) time.Time { return t.In(loc) },
+	}
+}
+
+// formatVideoDuration formats seconds as m:ss, or h:mm:ss from one hour up.
+func formatVideoDuration(seconds int) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	hours, minutes, secs := seconds/3600, seconds/60%60, seconds%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, secs)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, secs)
+}
+
+// unescapeTemplateText expands \t, \n, \r, and \\ in template text, leaving {{ actions }} untouched.
+func unescapeTemplateText(source string) string {
+	var b strings.Builder
+	for source != "" {
+		start := strings.Index(source, "{{")
+		if start < 0 {
+			b.WriteString(templateEscapes.Replace(source))
+			break
+		}
+		b.WriteString(templateEscapes.Replace(source[:start]))
+		end := strings.Index(source[start:], "}}")
+		if end < 0 {
+			b.WriteString(source[start:])
+			break
+		}
+		b.WriteString(source[start : start+end+2])
+		source = source[start+end+2:]
+	}
+	return b.String()
+}
+
+func (f templateFormatter) writeHeader(io.Writer) error { return nil }
+
+// writeVideos renders each video, ending every entry with a newline unless the template already did.
+func (f templateFormatter) writeVideos(w io.Writer, videos []niconico.Video) error {
+	writer := bufio.NewWriter(w)
+	var entry strings.Builder
+	for _, video := range videos {
+		entry.Reset()
+		if err := f.tmpl.Execute(&entry, video); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(entry.String(), "\n") {
+			entry.WriteByte('\n')
+		}
+		if _, err := writer.WriteString(entry.String()); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func (f templateFormatter) writeFooter(io.Writer) error { return nil }
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnescapeTemplateText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: `{{.ID}}\t{{.Title}}`, want: "{{.ID}}\t{{.Title}}"},
+		{in: `a\\tb\n`, want: "a\\tb\n"},
+		{in: `{{printf "%s\t" .ID}}\t`, want: "{{printf \"%s\\t\" .ID}}\t"},
+		{in: `x{{.ID`, want: "x{{.ID"},
+	}
+	for _, tt := range tests {
+		if got := unescapeTemplateText(tt.in); got != tt.want {
+			t.Fatalf("unescapeTemplateText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatVideoDuration(t *testing.T) {
+	tests := map[int]string{0: "0:00", 90: "1:30", 3599: "59:59", 3600: "1:00:00", 36125: "10:02:05", -1: "0:00"}
+	for seconds, want := range tests {
+		if got := formatVideoDuration(seconds); got != want {
+			t.Fatalf("formatVideoDuration(%d) = %q, want %q", seconds, got, want)
+		}
+	}
+}
+
+func TestTemplateFormatterHelpers(t *testing.T) {
+	cfg := newTestRootConfig()
+	cfg.Template = `{{.ID}}\t{{watchURL .ID}}\t{{duration .Duration}}\t{{json .Title}}\t{{if not .RegisteredAt.IsZero}}{{(local .RegisteredAt).Format "2006-01-02 15:04"}}{{end}}{{if .Series}}\t{{.Series.Title}}{{end}}`
+	want := "sm1\thttps://www.nicovideo.jp/watch/sm1\t1:30\t\"title, with \\\"quotes\\\"\"\t2024-01-02 12:04\n" +
+		"sm2\thttps://www.nicovideo.jp/watch/sm2\t0:00\t\"tab\\there\"\t\tseries\n"
+	if got := formatWithConfig(t, cfg); got != want {
+		t.Fatalf("unexpected template output:\n%q", got)
+	}
+}
+
+func TestTemplateFileKeepsTrailingNewline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "videos.tmpl")
+	if err := os.WriteFile(path, []byte("{{.ID}}:\n  {{.Title}}\n"), 0o600); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	cfg := newTestRootConfig()
+	cfg.TemplateFile = path
+	want := "sm1:\n  title, with \"quotes\"\nsm2:\n  tab\there\n"
+	if got := formatWithConfig(t, cfg); got != want {
+		t.Fatalf("unexpected template output:\n%q", got)
+	}
+}
+
+func TestTemplateValidation(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.tmpl")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--template", "{{.ID}}", "--template-file", missing}, want: "template and template-file cannot be combined"},
+		{args: []string{"--template", "{{.ID"}, want: "template: template:1: unclosed action"},
+		{args: []string{"--template", "{{plays .ID}}"}, want: `template: template:1: function "plays" not defined`},
+		{args: []string{"--template-file", missing}, want: "template-file: open " + missing + ": no such file or directory"},
+		{args: []string{"--format", "template"}, want: "format template requires template or template-file"},
+		{args: []string{"--format", "csv", "--template", "{{.ID}}"}, want: "template cannot be combined with format csv"},
+		{args: []string{"--json", "--template", "{{.ID}}"}, want: "template cannot be combined with format json"},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "nicovideo.jp/user/1")
+		_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), args...)
+		if err == nil || err.Error() != tt.want {
+			t.Fatalf("unexpected error for %v: %v", tt.args, err)
+		}
+	}
+}
+
+func TestRunRootCmdTemplateSortsOutput(t *testing.T) {
+	server := newFormatTestServer(t)
+	out, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(),
+		"--template", `{{.ID}}\t{{.Title}}\t{{.RegisteredAt.Format "2006-01-02"}}`, "nicovideo.jp/user/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); got != "sm1\tfirst\t2024-01-01\nsm2\tsecond\t2024-01-02\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if !strings.Contains(errOut.String(), "output_count=2") {
+		t.Fatalf("unexpected summary: %q", errOut.String())
+	}
+}
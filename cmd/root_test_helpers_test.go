@@ -82,6 +82,7 @@ func newEmptyAPIServer(t *testing.T) *httptest.Server {
 func testFetchConfig(serverURL string) RootConfig {
 	cfg := newTestRootConfig()
 	cfg.BaseURL = serverURL
+	cfg.SearchBaseURL = serverURL + "/search"
 	cfg.Retries = 1
 	cfg.Concurrency = 1
 	cfg.HTTPClientTimeout = time.Second
@@ -42,7 +42,7 @@ func TestDateRangeOrderValidation(t *testing.T) {
 
 func TestDateAfterFormatValidation(t *testing.T) {
 	cfg := newTestRootConfig()
-	cfg.DateAfter = "2025-01-01"
+	cfg.DateAfter = "2025/01/01"
 	cfg.DateBefore = "20250101"
 	_, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/user/1")
 	if err == nil || err.Error() != "dateafter format error" {
@@ -53,7 +53,7 @@ func TestDateAfterFormatValidation(t *testing.T) {
 func TestDateBeforeFormatValidation(t *testing.T) {
 	cfg := newTestRootConfig()
 	cfg.DateAfter = "20250101"
-	cfg.DateBefore = "2025-01-01"
+	cfg.DateBefore = "2025/01/01"
 	_, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(), "nicovideo.jp/user/1")
 	if err == nil || err.Error() != "datebefore format error" {
 		t.Fatalf("unexpected error: %v", err)
@@ -107,3 +107,69 @@ func TestPageConcurrencyFlagDocumentsPerTargetScope(t *testing.T) {
 		t.Fatalf("unexpected usage: %q", got)
 	}
 }
+
+func TestSortFlagsValidation(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--sort-key", "random"}, want: "sort-key must be one of registeredAt, addedAt, viewCount, commentCount, mylistCount, likeCount, lastCommentTime, duration"},
+		{args: []string{"--sort-key", "registeredAt", "--sort-order", "newest"}, want: "sort-order must be asc or desc"},
+		{args: []string{"--sort-order", "asc"}, want: "sort-order requires sort-key"},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "nicovideo.jp/user/1")
+		_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), args...)
+		if err == nil || err.Error() != tt.want {
+			t.Fatalf("unexpected error for %v: %v", tt.args, err)
+		}
+	}
+}
+
+func TestRangeFlagsValidation(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--min-views", "-1"}, want: "min-views and max-views must be at least 0"},
+		{args: []string{"--min-likes", "10", "--max-likes", "5"}, want: "min-likes must be less than or equal to max-likes"},
+		{args: []string{"--max-mylists", "-3"}, want: "min-mylists and max-mylists must be at least 0"},
+		{args: []string{"--min-comment", "2", "--max-comment", "1"}, want: "min-comment must be less than or equal to max-comment"},
+		{args: []string{"--min-duration", "10m", "--max-duration", "5m"}, want: "min-duration must be less than or equal to max-duration"},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "nicovideo.jp/user/1")
+		_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), args...)
+		if err == nil || err.Error() != tt.want {
+			t.Fatalf("unexpected error for %v: %v", tt.args, err)
+		}
+	}
+}
+
+func TestFilterExprValidation(t *testing.T) {
+	_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), "--filter", "views > 10 && plays > 5", "nicovideo.jp/user/1")
+	if err == nil || err.Error() != `filter column 15: unknown field "plays"` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTextFilterValidation(t *testing.T) {
+	_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), "--desc-exclude", "(archive", "nicovideo.jp/user/1")
+	if err == nil || err.Error() != "desc-exclude: error parsing regexp: missing closing ): `(archive`" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestOwnerTypeValidation(t *testing.T) {
+	_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), "--owner-type", "company", "nicovideo.jp/user/1")
+	if err == nil || err.Error() != "owner-type must be user or channel" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTimezoneValidation(t *testing.T) {
+	_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), "--timezone", "Mars/Olympus", "nicovideo.jp/user/1")
+	if err == nil || err.Error() != "timezone: unknown time zone Mars/Olympus" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,404 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultServeAddr        = "127.0.0.1:8080"
+	defaultResponseTTL      = time.Minute
+	serveReadHeaderTimeout  = 10 * time.Second
+	serveShutdownTimeout    = 10 * time.Second
+	cacheStatusHit          = "hit"
+	cacheStatusMiss         = "miss"
+	cacheStatusShared       = "shared"
+	searchKeywordQueryParam = "q"
+)
+
+// serveRootFlags are the root flags that also configure serve.
+var serveRootFlags = []string{"rate-limit", "min-interval", "retries", "timeout", "page-concurrency", "timezone", "schema-version", "logfile"}
+
+// newServeCommand creates the serve subcommand, which shares the root fetch flags.
+func newServeCommand(root *cobra.Command, cfg *RootConfig, deps RootDeps) *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "serve target video lists as --json payloads over HTTP",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			applyServiceRateLimit(cmd, cfg)
+			return runServe(cmd, cfg, deps)
+		},
+	}
+	for _, name := range serveRootFlags {
+		serveCmd.Flags().AddFlag(root.Flags().Lookup(name))
+	}
+	serveCmd.Flags().StringVar(&cfg.ServeAddr, "addr", cfg.ServeAddr, "listen `address`")
+	serveCmd.Flags().DurationVar(&cfg.ResponseTTL, "response-ttl", cfg.ResponseTTL, "reuse a successful response for identical requests this long (0 only coalesces concurrent requests)")
+	return serveCmd
+}
+
+// runServe listens on --addr until the command context is canceled, then shuts down gracefully.
+func runServe(cmd *cobra.Command, cfg *RootConfig, deps RootDeps) (retErr error) {
+	if err := validateFlagsFor(cfg); err != nil {
+		return err
+	}
+	if cfg.ResponseTTL < 0 {
+		return errors.New("response-ttl must be at least 0")
+	}
+	runLogger, cleanup, err := setupLoggerFor(cfg.LogFilePath, deps)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := cleanup(); retErr == nil && err != nil {
+			retErr = err
+		}
+	}()
+
+	ctx := context.Background()
+	if cmd != nil {
+		ctx = cmd.Context()
+	}
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", cfg.ServeAddr)
+	if err != nil {
+		return err
+	}
+	api := newAPIServer(ctx, cfg, deps, runLogger)
+	server := &http.Server{Handler: api.handler(), ReadHeaderTimeout: serveReadHeaderTimeout}
+	if _, err := fmt.Fprintf(errWriterFor(cmd), "listening on %s\n", listener.Addr()); err != nil {
+		_ = listener.Close()
+		return err
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(listener) }()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// apiServer answers target requests with --json payloads, sharing one client (and its RateLimiter), one response cache, and one set of metrics.
+type apiServer struct {
+	// ctx bounds fetches, which outlive the request that started them when other requests share the result.
+	ctx     context.Context
+	cfg     *RootConfig
+	deps    RootDeps
+	client  *niconico.Client
+	cache   *responseCache
+	metrics *runMetrics
+	logger  *slog.Logger
+}
+
+// newAPIServer builds the serve handler state.
+func newAPIServer(ctx context.Context, cfg *RootConfig, deps RootDeps, logger *slog.Logger) *apiServer {
+	deps = normalizeRootDeps(deps)
+	metrics := newRunMetrics()
+	return &apiServer{
+		ctx:     ctx,
+		cfg:     cfg,
+		deps:    deps,
+		client:  newNiconicoClient(cfg, logger, metrics),
+		cache:   newResponseCache(cfg.ResponseTTL, deps.Now),
+		metrics: metrics,
+		logger:  logger,
+	}
+}
+
+// handler routes the v1 API.
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/users/{id}/videos", s.targetHandler(targetTypeUser))
+	mux.HandleFunc("GET /v1/mylists/{id}", s.targetHandler(targetTypeMylist))
+	mux.HandleFunc("GET /v1/series/{id}", s.targetHandler(targetTypeSeries))
+	mux.HandleFunc("GET /v1/channels/{id}", s.targetHandler(targetTypeChannel))
+	mux.HandleFunc("GET /v1/tags/{id}", s.targetHandler(targetTypeTag))
+	mux.HandleFunc("GET /v1/search", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		keyword := query.Get(searchKeywordQueryParam)
+		query.Del(searchKeywordQueryParam)
+		s.serveTarget(w, r, targetTypeSearch, keyword, query)
+	})
+	mux.Handle("GET /metrics", s.metrics)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeAPIJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return mux
+}
+
+// targetHandler serves the target named by the {id} path value.
+func (s *apiServer) targetHandler(targetType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.serveTarget(w, r, targetType, r.PathValue("id"), r.URL.Query())
+	}
+}
+
+// serveTarget validates the request, then answers from the response cache or a coalesced fetch.
+func (s *apiServer) serveTarget(w http.ResponseWriter, r *http.Request, targetType, id string, query url.Values) {
+	target, ok := serveTargetFor(targetType, id)
+	if !ok {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s id: %q", targetType, id))
+		return
+	}
+	cfg, err := serveRequestConfig(s.cfg, query)
+	if err == nil {
+		err = validateSearchExclusions(cfg, []inputTarget{target})
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter, err := newFilter(cfg, s.deps.Now())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	key := targetStateKey(target) + "?" + query.Encode()
+	response, status, err := s.cache.do(r.Context(), key, func() apiResponse {
+		return s.fetch(target, cfg.SchemaVersion, filter)
+	})
+	if err != nil {
+		// The client went away while waiting for a shared fetch.
+		return
+	}
+	s.logger.Info("serve request", "path", r.URL.Path, "status", response.status, "cache", status)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Cache", status)
+	w.WriteHeader(response.status)
+	_, _ = w.Write(response.body)
+}
+
+// fetch builds the --json payload for one target; fetch errors are reported in the payload with status 502,
+// and a fetch cut short by shutdown is answered with 503, which the cache does not store.
+func (s *apiServer) fetch(target inputTarget, schemaVersion int, filter niconico.Filter) apiResponse {
+	startedAt := s.deps.Now()
+	videos, err := fetchTargetList(s.ctx, s.client, target, filter)
+	if s.ctx.Err() != nil {
+		// The canceled fetch returns what it had as a success, which would be an empty or partial list.
+		return apiResponse{status: http.StatusServiceUnavailable, body: apiErrorBody("server is shutting down")}
+	}
+	result := targetResult{
+		Type:       target.Type,
+		ID:         target.ID,
+		Items:      niconico.VideoIDs(videos),
+		Videos:     videos,
+		Input:      targetInput(target),
+		StartedAt:  startedAt,
+		FinishedAt: s.deps.Now(),
+	}
+	s.metrics.observeTarget(err == nil)
+	status := http.StatusOK
+	errorsList := make([]string, 0)
+	if err != nil {
+		s.logger.Error("failed to get video list", "error", err)
+		result.Error = err.Error()
+		errorsList = append(errorsList, err.Error())
+		status = http.StatusBadGateway
+	}
+	outputVideos := slices.Clone(videos)
+	niconico.SortVideos(outputVideos)
+	s.metrics.observeItems(len(outputVideos))
+	results := []targetResult{result}
+	payload := buildJSONOutput(1, 1, 0, nil, results, errorsList, len(outputVideos), outputVideos)
+	body, err := json.Marshal(jsonOutputForVersion(schemaVersion, payload, results))
+	if err != nil {
+		return apiResponse{status: http.StatusInternalServerError, body: apiErrorBody(err.Error())}
+	}
+	return apiResponse{status: status, body: append(body, '\n')}
+}
+
+// serveTargetFor builds a target from a route value, accepting only IDs the CLI accepts as input.
+func serveTargetFor(targetType, id string) (inputTarget, bool) {
+	input := targetInput(inputTarget{Type: targetType, ID: id})
+	if targetType == targetTypeChannel {
+		input = "ch.nicovideo.jp/" + id
+	}
+	target, ok := parseInputTarget(input)
+	return target, ok && target.Type == targetType && target.ID == id
+}
+
+// serveQueryParams sets the filter fields named like their root flags; after and before are short for dateafter and datebefore.
+var serveQueryParams = map[string]func(cfg *RootConfig, value string) error{
+	"comment":           intParam(func(cfg *RootConfig) *int { return &cfg.Comment }),
+	"after":             stringParam(func(cfg *RootConfig) *string { return &cfg.DateAfter }),
+	"before":            stringParam(func(cfg *RootConfig) *string { return &cfg.DateBefore }),
+	"dateafter":         stringParam(func(cfg *RootConfig) *string { return &cfg.DateAfter }),
+	"datebefore":        stringParam(func(cfg *RootConfig) *string { return &cfg.DateBefore }),
+	"timezone":          stringParam(func(cfg *RootConfig) *string { return &cfg.Timezone }),
+	"min-views":         intParam(func(cfg *RootConfig) *int { return &cfg.MinViews }),
+	"max-views":         intParam(func(cfg *RootConfig) *int { return &cfg.MaxViews }),
+	"min-likes":         intParam(func(cfg *RootConfig) *int { return &cfg.MinLikes }),
+	"max-likes":         intParam(func(cfg *RootConfig) *int { return &cfg.MaxLikes }),
+	"min-mylists":       intParam(func(cfg *RootConfig) *int { return &cfg.MinMylists }),
+	"max-mylists":       intParam(func(cfg *RootConfig) *int { return &cfg.MaxMylists }),
+	"min-comment":       intParam(func(cfg *RootConfig) *int { return &cfg.MinComment }),
+	"max-comment":       intParam(func(cfg *RootConfig) *int { return &cfg.MaxComment }),
+	"min-duration":      durationParam(func(cfg *RootConfig) *time.Duration { return &cfg.MinDuration }),
+	"max-duration":      durationParam(func(cfg *RootConfig) *time.Duration { return &cfg.MaxDuration }),
+	"filter":            stringParam(func(cfg *RootConfig) *string { return &cfg.FilterExpr }),
+	"title-match":       stringParam(func(cfg *RootConfig) *string { return &cfg.TitleMatch }),
+	"title-exclude":     stringParam(func(cfg *RootConfig) *string { return &cfg.TitleExclude }),
+	"desc-match":        stringParam(func(cfg *RootConfig) *string { return &cfg.DescMatch }),
+	"desc-exclude":      stringParam(func(cfg *RootConfig) *string { return &cfg.DescExclude }),
+	"ignore-case":       boolParam(func(cfg *RootConfig) *bool { return &cfg.IgnoreCase }),
+	"normalize-width":   boolParam(func(cfg *RootConfig) *bool { return &cfg.NormalizeWidth }),
+	"exclude-channel":   boolParam(func(cfg *RootConfig) *bool { return &cfg.ExcludeChannel }),
+	"exclude-paid":      boolParam(func(cfg *RootConfig) *bool { return &cfg.ExcludePaid }),
+	"exclude-sensitive": boolParam(func(cfg *RootConfig) *bool { return &cfg.ExcludeSensitive }),
+	"owner-type":        stringParam(func(cfg *RootConfig) *string { return &cfg.OwnerType }),
+	"schema-version":    intParam(func(cfg *RootConfig) *int { return &cfg.SchemaVersion }),
+}
+
+func intParam(field func(cfg *RootConfig) *int) func(cfg *RootConfig, value string) error {
+	return func(cfg *RootConfig, value string) error {
+		parsed, err := strconv.Atoi(value)
+		*field(cfg) = parsed
+		return err
+	}
+}
+
+func durationParam(field func(cfg *RootConfig) *time.Duration) func(cfg *RootConfig, value string) error {
+	return func(cfg *RootConfig, value string) error {
+		parsed, err := time.ParseDuration(value)
+		*field(cfg) = parsed
+		return err
+	}
+}
+
+func boolParam(field func(cfg *RootConfig) *bool) func(cfg *RootConfig, value string) error {
+	return func(cfg *RootConfig, value string) error {
+		parsed, err := strconv.ParseBool(value)
+		*field(cfg) = parsed
+		return err
+	}
+}
+
+func stringParam(field func(cfg *RootConfig) *string) func(cfg *RootConfig, value string) error {
+	return func(cfg *RootConfig, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+// serveRequestConfig applies the query parameters to a copy of the server config and validates it like the CLI flags.
+func serveRequestConfig(base *RootConfig, query url.Values) (*RootConfig, error) {
+	cfg := *base
+	for _, name := range slices.Sorted(maps.Keys(query)) {
+		set, ok := serveQueryParams[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown query parameter %s", name)
+		}
+		values := query[name]
+		if err := set(&cfg, values[len(values)-1]); err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", name, values[len(values)-1])
+		}
+	}
+	if err := validateFlagsFor(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// apiResponse is a complete response body and status, as stored in the response cache.
+type apiResponse struct {
+	status   int
+	body     []byte
+	storedAt time.Time
+}
+
+// responseCache keeps successful responses for ttl and runs one load for concurrent requests with the same key.
+type responseCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]apiResponse
+	flights map[string]*responseFlight
+}
+
+// responseFlight is a load in progress; done is closed once response is set.
+type responseFlight struct {
+	done     chan struct{}
+	response apiResponse
+}
+
+// newResponseCache returns an empty cache; a ttl of 0 only coalesces concurrent requests.
+func newResponseCache(ttl time.Duration, now func() time.Time) *responseCache {
+	return &responseCache{ttl: ttl, now: now, entries: make(map[string]apiResponse), flights: make(map[string]*responseFlight)}
+}
+
+// do returns the cached response for key, waits for a load already in progress, or runs load itself.
+// The second result is the cache status: hit, shared, or miss. An error means ctx ended while waiting.
+func (c *responseCache) do(ctx context.Context, key string, load func() apiResponse) (apiResponse, string, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && c.now().Sub(entry.storedAt) < c.ttl {
+		c.mu.Unlock()
+		return entry, cacheStatusHit, nil
+	}
+	if flight, ok := c.flights[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-flight.done:
+			return flight.response, cacheStatusShared, nil
+		case <-ctx.Done():
+			return apiResponse{}, "", ctx.Err()
+		}
+	}
+	flight := &responseFlight{done: make(chan struct{})}
+	c.flights[key] = flight
+	c.mu.Unlock()
+
+	response := load()
+	response.storedAt = c.now()
+	c.mu.Lock()
+	delete(c.flights, key)
+	if response.status == http.StatusOK && c.ttl > 0 {
+		for cachedKey, entry := range c.entries {
+			if response.storedAt.Sub(entry.storedAt) >= c.ttl {
+				delete(c.entries, cachedKey)
+			}
+		}
+		c.entries[key] = response
+	}
+	flight.response = response
+	c.mu.Unlock()
+	close(flight.done)
+	return response, cacheStatusMiss, nil
+}
+
+// writeAPIError writes a JSON error body.
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(apiErrorBody(message))
+}
+
+// writeAPIJSON writes value as a JSON body.
+func writeAPIJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// apiErrorBody returns {"error": message} followed by a newline.
+func apiErrorBody(message string) []byte {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	return append(body, '\n')
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestAPIServer returns an httptest server for the serve API in front of upstreamURL.
+func newTestAPIServer(t *testing.T, upstreamURL string, ttl time.Duration, now func() time.Time) *httptest.Server {
+	t.Helper()
+	cfg := testFetchConfig(upstreamURL)
+	cfg.ResponseTTL = ttl
+	deps := newTestRootDeps()
+	deps.Now = now
+	api := newAPIServer(context.Background(), &cfg, deps, slog.New(slog.DiscardHandler))
+	server := httptest.NewServer(api.handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// getAPI requests path from server and returns the status, X-Cache header, and body.
+func getAPI(t *testing.T, server *httptest.Server, path string) (int, string, string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("get %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return resp.StatusCode, resp.Header.Get("X-Cache"), string(body)
+}
+
+func fixedNow() time.Time {
+	return time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+}
+
+func TestServeMatchesJSONOutput(t *testing.T) {
+	upstream := newFormatTestServer(t)
+	deps := newTestRootDeps()
+	deps.Now = fixedNow
+	want, _, err := executeTestRootCommand(t, testFetchConfig(upstream.URL), deps, "--json", "--comment", "0", "--dateafter", "2024-01-02", "nicovideo.jp/user/1")
+	if err != nil {
+		t.Fatalf("unexpected CLI error: %v", err)
+	}
+	server := newTestAPIServer(t, upstream.URL, time.Minute, fixedNow)
+	status, cache, body := getAPI(t, server, "/v1/users/1/videos?comment=0&after=2024-01-02")
+	if status != http.StatusOK || cache != cacheStatusMiss {
+		t.Fatalf("unexpected response: %d %s %s", status, cache, body)
+	}
+	if body != want.String() {
+		t.Fatalf("serve payload differs from --json:\n%s\nwant:\n%s", body, want.String())
+	}
+	if !strings.Contains(body, `"sm2"`) || strings.Contains(body, `"sm1"`) {
+		t.Fatalf("after was not applied: %s", body)
+	}
+}
+
+func TestServeCoalescesAndCachesRequests(t *testing.T) {
+	var requests atomic.Int64
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "1" {
+			requests.Add(1)
+			<-release
+			_, _ = io.WriteString(w, `{"data":{"items":[{"essential":{"id":"sm1","registeredAt":"2024-01-01T00:00:00Z","count":{"comment":10}}}]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+	}))
+	defer upstream.Close()
+	var mu sync.Mutex
+	now := fixedNow()
+	server := newTestAPIServer(t, upstream.URL, time.Minute, func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	})
+
+	const clients = 4
+	var wg sync.WaitGroup
+	statuses := make(chan string, clients)
+	for range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, cache, _ := getAPI(t, server, "/v1/mylists/10")
+			statuses <- cache
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("upstream was not requested")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// Give the other requests time to join the fetch in flight before it completes.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(statuses)
+	counts := map[string]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	if requests.Load() != 1 || counts[cacheStatusMiss] != 1 || counts[cacheStatusShared] != clients-1 {
+		t.Fatalf("expected one upstream fetch shared by all clients, got %d fetches and %v", requests.Load(), counts)
+	}
+
+	if _, cache, _ := getAPI(t, server, "/v1/mylists/10"); cache != cacheStatusHit || requests.Load() != 1 {
+		t.Fatalf("expected a cache hit, got %s after %d fetches", cache, requests.Load())
+	}
+	// Different parameters are a different request.
+	if _, cache, _ := getAPI(t, server, "/v1/mylists/10?comment=0"); cache != cacheStatusMiss || requests.Load() != 2 {
+		t.Fatalf("expected a miss for other parameters, got %s after %d fetches", cache, requests.Load())
+	}
+	mu.Lock()
+	now = now.Add(time.Minute)
+	mu.Unlock()
+	if _, cache, _ := getAPI(t, server, "/v1/mylists/10"); cache != cacheStatusMiss || requests.Load() != 3 {
+		t.Fatalf("expected the entry to expire, got %s after %d fetches", cache, requests.Load())
+	}
+}
+
+func TestServeDoesNotCacheFetchErrors(t *testing.T) {
+	var requests atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstream.Close()
+	server := newTestAPIServer(t, upstream.URL, time.Minute, fixedNow)
+	for range 2 {
+		status, cache, body := getAPI(t, server, "/v1/series/5")
+		if status != http.StatusBadGateway || cache != cacheStatusMiss || !strings.Contains(body, `"errors":["unexpected status: 500"]`) {
+			t.Fatalf("unexpected response: %d %s %s", status, cache, body)
+		}
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expected errors to be fetched again, got %d requests", requests.Load())
+	}
+}
+
+func TestServeAnswersUnavailableDuringShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	upstream := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+	cfg := testFetchConfig(upstream.URL)
+	cfg.ResponseTTL = time.Minute
+	api := newAPIServer(ctx, &cfg, newTestRootDeps(), slog.New(slog.DiscardHandler))
+	server := httptest.NewServer(api.handler())
+	defer server.Close()
+	// The fetch canceled by shutdown returns no videos, which must not be served or cached as the target's list.
+	status, _, body := getAPI(t, server, "/v1/mylists/10")
+	if status != http.StatusServiceUnavailable || body != "{\"error\":\"server is shutting down\"}\n" {
+		t.Fatalf("unexpected response: %d %s", status, body)
+	}
+	if len(api.cache.entries) != 0 {
+		t.Fatalf("expected nothing cached, got %d entries", len(api.cache.entries))
+	}
+}
+
+func TestServeRejectsBadRequests(t *testing.T) {
+	server := newTestAPIServer(t, "http://127.0.0.1:0", time.Minute, fixedNow)
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{path: "/v1/users/abc/videos", status: http.StatusBadRequest, body: `{"error":"invalid user id: \"abc\""}`},
+		{path: "/v1/mylists/1?sort=asc", status: http.StatusBadRequest, body: `{"error":"unknown query parameter sort"}`},
+		{path: "/v1/mylists/1?comment=many", status: http.StatusBadRequest, body: `{"error":"invalid comment: \"many\""}`},
+		{path: "/v1/mylists/1?after=someday", status: http.StatusBadRequest},
+		{path: "/v1/search", status: http.StatusBadRequest, body: `{"error":"invalid search id: \"\""}`},
+		{path: "/v1/tags/MMD?exclude-paid=true", status: http.StatusBadRequest, body: `{"error":"exclude-paid and exclude-sensitive cannot be used with tag target \"MMD\": search results do not report those flags"}`},
+		{path: "/v1/users/1", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		status, _, body := getAPI(t, server, tt.path)
+		if status != tt.status || (tt.body != "" && body != tt.body+"\n") {
+			t.Fatalf("%s: unexpected response %d %s", tt.path, status, body)
+		}
+	}
+}
+
+func TestServeStopsWhenContextIsCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stderr, stderrWriter := io.Pipe()
+	deps := newTestRootDeps()
+	deps.Stderr = stderrWriter
+	cmd, _, _ := newTestRootCommand(t, newTestRootConfig(), deps)
+	cmd.SetContext(ctx)
+	cmd.SetArgs([]string{"serve", "--addr", "127.0.0.1:0"})
+	done := make(chan error, 1)
+	go func() { done <- cmd.Execute() }()
+	line, err := bufio.NewReader(stderr).ReadString('\n')
+	addr, ok := strings.CutPrefix(strings.TrimSpace(line), "listening on ")
+	if err != nil || !ok {
+		t.Fatalf("unexpected stderr: %q, %v", line, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/healthz", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("healthz: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected healthz status: %d", resp.StatusCode)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not stop after cancel")
+	}
+}
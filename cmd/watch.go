@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultWatchInterval = 10 * time.Minute
+	defaultWatchJitter   = time.Minute
+	// defaultServiceRateLimit is the requests per second of watch and serve when no limit is given.
+	defaultServiceRateLimit = 1.0
+	defaultWebhookRetries   = 3
+	defaultSinkRetryWait    = time.Second
+	defaultExecTimeout      = time.Minute
+)
+
+// newWatchCommand creates the watch subcommand, which accepts the root fetch, filter, and output flags.
+func newWatchCommand(root *cobra.Command, cfg *RootConfig, deps RootDeps) *cobra.Command {
+	watchCmd := &cobra.Command{
+		Use:   "watch [targets...]",
+		Short: "poll targets on an interval and print only videos not seen before",
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyServiceRateLimit(cmd, cfg)
+			return runWatch(cmd, args, cfg, deps)
+		},
+	}
+	watchCmd.Flags().AddFlagSet(root.Flags())
+	watchCmd.Flags().DurationVar(&cfg.WatchInterval, "interval", cfg.WatchInterval, "time between the end of one poll and the start of the next")
+	watchCmd.Flags().DurationVar(&cfg.WatchJitter, "jitter", cfg.WatchJitter, "add a random delay up to this long to each interval (0 disables)")
+	watchCmd.Flags().StringVar(&cfg.SeenFilePath, "seen-file", cfg.SeenFilePath, "keep the video IDs seen per target in this `file` across restarts")
+	watchCmd.Flags().IntVar(&cfg.WatchPolls, "polls", cfg.WatchPolls, "stop after this many polls (0 runs until interrupted)")
+	watchCmd.Flags().BoolVar(&cfg.EmitInitial, "emit-initial", cfg.EmitInitial, "print the videos of a target's first poll instead of only recording them")
+	watchCmd.Flags().StringArrayVar(&cfg.Webhooks, "webhook", cfg.Webhooks, "POST new videos as JSON to this `url` (repeatable)")
+	watchCmd.Flags().StringVar(&cfg.WebhookFormat, "webhook-format", cfg.WebhookFormat, "webhook payload `shape`: generic, discord, or slack")
+	watchCmd.Flags().BoolVar(&cfg.WebhookBatch, "webhook-batch", cfg.WebhookBatch, "send one webhook request per poll instead of one per video")
+	watchCmd.Flags().IntVar(&cfg.WebhookRetries, "webhook-retries", cfg.WebhookRetries, "attempts per webhook request")
+	watchCmd.Flags().DurationVar(&cfg.WebhookRetryWait, "webhook-retry-wait", cfg.WebhookRetryWait, "wait before the first webhook retry, doubled after each")
+	watchCmd.Flags().StringVar(&cfg.ExecCommand, "exec", cfg.ExecCommand, "run this `command` per new video; {id}, {url}, and {title} are replaced and the video JSON is on stdin")
+	watchCmd.Flags().IntVar(&cfg.ExecConcurrency, "exec-concurrency", cfg.ExecConcurrency, "number of --exec commands run at once")
+	watchCmd.Flags().IntVar(&cfg.ExecRetries, "exec-retries", cfg.ExecRetries, "attempts per --exec command")
+	watchCmd.Flags().DurationVar(&cfg.ExecRetryWait, "exec-retry-wait", cfg.ExecRetryWait, "wait before the first --exec retry, doubled after each")
+	watchCmd.Flags().DurationVar(&cfg.ExecTimeout, "exec-timeout", cfg.ExecTimeout, "kill an --exec command after this long")
+	watchCmd.Flags().StringVar(&cfg.MetricsAddr, "metrics-addr", cfg.MetricsAddr, "serve Prometheus metrics on http://`address`/metrics (empty disables)")
+	return watchCmd
+}
+
+// applyServiceRateLimit sets the default rate limit of the long-running subcommands unless a limit was chosen explicitly.
+func applyServiceRateLimit(cmd *cobra.Command, cfg *RootConfig) {
+	if !cmd.Flags().Changed("rate-limit") && !cmd.Flags().Changed("min-interval") && cfg.RateLimit == 0 && cfg.MinInterval == 0 {
+		cfg.RateLimit = defaultServiceRateLimit
+	}
+}
+
+// validateWatchFlags checks the flags that only apply to, or cannot be used with, watch.
+func validateWatchFlags(cfg *RootConfig) error {
+	if cfg.WatchInterval <= 0 {
+		return errors.New("interval must be greater than 0")
+	}
+	if cfg.WatchJitter < 0 {
+		return errors.New("jitter must be at least 0")
+	}
+	if cfg.WatchPolls < 0 {
+		return errors.New("polls must be at least 0")
+	}
+	if format := outputFormatFor(cfg); isRunFormat(format) || format == formatAtom || format == formatRSS {
+		return fmt.Errorf("watch does not support format %s", format)
+	}
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{name: "stdin", set: cfg.ReadStdin},
+		{name: "state-file", set: cfg.StateFilePath != ""},
+		{name: "since-output", set: cfg.SinceOutput != ""},
+		{name: "feed-dir", set: cfg.FeedDir != ""},
+		{name: "json-schema", set: cfg.PrintJSONSchema},
+	} {
+		if flag.set {
+			return fmt.Errorf("%s cannot be combined with watch", flag.name)
+		}
+	}
+	return validateNotifyFlags(cfg)
+}
+
+// runWatch polls the targets until ctx is canceled or --polls is reached, writing each poll's unseen videos as one batch.
+func runWatch(cmd *cobra.Command, args []string, cfg *RootConfig, deps RootDeps) (retErr error) {
+	if err := validateFlagsFor(cfg); err != nil {
+		return err
+	}
+	if err := validateSearchExclusions(cfg, argTargets(args)); err != nil {
+		return err
+	}
+	if err := validateWatchFlags(cfg); err != nil {
+		return err
+	}
+	formatter, err := newVideoFormatter(cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := newFilter(cfg, deps.Now()); err != nil {
+		return err
+	}
+	seen, err := loadSeenVideos(cfg.SeenFilePath)
+	if err != nil {
+		return err
+	}
+
+	runLogger, cleanup, err := setupLoggerFor(cfg.LogFilePath, deps)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := cleanup(); retErr == nil && err != nil {
+			retErr = err
+		}
+	}()
+
+	ctx := context.Background()
+	if cmd != nil {
+		ctx = cmd.Context()
+	}
+	out := outWriterFor(cmd)
+	errWriter := errWriterFor(cmd)
+	// One client for the whole watch, so every poll and target shares its RateLimiter.
+	metrics := newRunMetrics()
+	client := newNiconicoClient(cfg, runLogger, metrics)
+	sinks := newNotifySinks(cfg, runLogger)
+	sinkKeys := make([]string, 0, len(sinks))
+	for _, sink := range sinks {
+		sinkKeys = append(sinkKeys, sink.key())
+	}
+	seen.keepPending(sinkKeys)
+	if cfg.MetricsAddr != "" {
+		addr, shutdown, err := startMetricsServer(ctx, cfg.MetricsAddr, metrics)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := shutdown(); retErr == nil && err != nil {
+				retErr = err
+			}
+		}()
+		if _, err := fmt.Fprintf(errWriter, "metrics listening on %s\n", addr); err != nil {
+			return err
+		}
+	}
+
+	if err := formatter.writeHeader(out); err != nil {
+		return err
+	}
+	for poll := 1; ; poll++ {
+		filter, err := newFilter(cfg, deps.Now())
+		if err != nil {
+			return err
+		}
+		results, summary, err := pollTargets(ctx, cmd, args, cfg, deps, client, filter, runLogger)
+		if err != nil {
+			if poll == 1 {
+				return err
+			}
+			runLogger.Error("failed to read inputs", "error", err)
+		}
+		if ctx.Err() != nil {
+			// Targets not fetched before the cancellation would be recorded as empty, so an interrupted poll records nothing.
+			if _, err := fmt.Fprintf(errWriter, "%s poll=%d\n", summary, poll); err != nil {
+				return err
+			}
+			break
+		}
+		videos := make([]niconico.Video, 0)
+		for _, result := range results {
+			metrics.observeTarget(result.Error == "")
+			if result.Error == "" {
+				videos = append(videos, seen.observe(inputTarget{Type: result.Type, ID: result.ID}, result.Videos, cfg.EmitInitial)...)
+			}
+		}
+		if !cfg.NoSortOutput {
+			niconico.SortVideos(videos)
+		}
+		summary.OutputCount = len(videos)
+		metrics.observeItems(len(videos))
+		runLogger.Info("watch poll", "poll", poll, "count", len(videos))
+		if len(videos) > 0 {
+			if err := formatter.writeVideos(out, videos); err != nil {
+				return err
+			}
+		}
+		line := fmt.Sprintf("%s poll=%d", summary, poll)
+		for _, stats := range deliverPending(ctx, sinks, seen, videos, runLogger) {
+			line += " " + stats.String()
+		}
+		if err := seen.save(); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(errWriter, line); err != nil {
+			return err
+		}
+		if err := writeMetricsFile(cfg.MetricsFile, metrics, deps.Now()); err != nil {
+			return err
+		}
+		if cfg.WatchPolls > 0 && poll >= cfg.WatchPolls {
+			break
+		}
+		if !sleepContext(ctx, watchDelay(cfg.WatchInterval, cfg.WatchJitter)) {
+			break
+		}
+	}
+	return formatter.writeFooter(out)
+}
+
+// pollTargets fetches every input target once and returns the results in target order.
+func pollTargets(ctx context.Context, cmd *cobra.Command, args []string, cfg *RootConfig, deps RootDeps, client *niconico.Client, filter niconico.Filter, runLogger *slog.Logger) ([]targetResult, runSummary, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream := streamInputsWithConfig(ctx, cmd, args, cfg, deps, newTargetExpander(client))
+
+	var summary runSummary
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make([]targetResult, 0)
+	sem := make(chan struct{}, cfg.Concurrency)
+	nextTargetOrder := 0
+	for streamed := range stream.inputs {
+		summary.Inputs++
+		target, ok := parseInputTarget(streamed.value)
+		if !ok {
+			summary.Invalid++
+			runLogger.Warn("invalid input", "input", streamed.value)
+			continue
+		}
+		summary.Valid++
+		result := targetResult{
+			Order:  nextTargetOrder,
+			Type:   target.Type,
+			ID:     target.ID,
+			Parent: targetParentFor(streamed.parent),
+			Items:  []string{},
+			Input:  streamed.value,
+		}
+		nextTargetOrder++
+		if streamed.err != nil {
+			runLogger.Error("failed to get video list", "error", streamed.err)
+			result.Error = streamed.err.Error()
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(target inputTarget, result targetResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result.StartedAt = deps.Now()
+			videos, err := fetchTargetList(ctx, client, target, filter)
+			if ctx.Err() != nil {
+				// A fetch cut short by cancellation returns what it had as a success; it is not a result.
+				return
+			}
+			result.FinishedAt = deps.Now()
+			result.Videos = videos
+			result.Items = niconico.VideoIDs(videos)
+			if err != nil {
+				runLogger.Error("failed to get video list", "error", err)
+				result.Error = err.Error()
+			}
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(target, result)
+	}
+	wg.Wait()
+	for _, result := range results {
+		if result.Error == "" {
+			summary.FetchOK++
+		} else {
+			summary.FetchErr++
+		}
+	}
+	sortTargetResults(results)
+	var inputErr error
+	for err := range stream.errs {
+		if err != nil && inputErr == nil {
+			inputErr = err
+		}
+	}
+	return results, summary, inputErr
+}
+
+// watchDelay returns interval plus a random jitter in [0, jitter).
+func watchDelay(interval, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	return interval + rand.N(jitter)
+}
+
+// sleepContext waits for d and reports false if ctx was canceled first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
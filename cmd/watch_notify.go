@@ -0,0 +1,522 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+const (
+	webhookFormatGeneric = "generic"
+	webhookFormatDiscord = "discord"
+	webhookFormatSlack   = "slack"
+
+	// discordMaxEmbeds is the number of embeds Discord accepts in one message.
+	discordMaxEmbeds = 10
+	// execOutputLimit caps the command output quoted in an exec error.
+	execOutputLimit = 512
+	// maxPendingPolls is how many polls try a delivery that keeps failing before it is dropped.
+	maxPendingPolls = 10
+)
+
+// webhookFormats lists the --webhook-format payload shapes.
+var webhookFormats = []string{webhookFormatGeneric, webhookFormatDiscord, webhookFormatSlack}
+
+// notifySink delivers the new videos of one watch poll to one external destination.
+type notifySink interface {
+	// key identifies the destination in the seen file's pending deliveries.
+	key() string
+	notify(ctx context.Context, videos []niconico.Video) sinkStats
+}
+
+// sinkStats counts one sink's successful and failed deliveries in a poll.
+type sinkStats struct {
+	name    string
+	ok      int
+	failed  int
+	dropped int
+	// retry lists the videos of failed deliveries that may succeed in a later poll.
+	retry []niconico.Video
+}
+
+// String formats the stats as summary fields.
+func (s sinkStats) String() string {
+	return fmt.Sprintf("%s_ok=%d %s_err=%d %s_dropped=%d", s.name, s.ok, s.name, s.failed, s.name, s.dropped)
+}
+
+// deliverPending sends each sink the videos it still has pending from earlier polls followed by videos, and keeps the
+// retryable failures pending in seen for the next poll. Permanent failures and videos that have been pending for
+// maxPendingPolls polls are dropped. The returned stats are merged per sink name, in sink order.
+func deliverPending(ctx context.Context, sinks []notifySink, seen *seenVideos, videos []niconico.Video, logger *slog.Logger) []sinkStats {
+	merged := make([]sinkStats, 0, len(sinks))
+	for _, sink := range sinks {
+		pending := seen.pendingFor(sink.key())
+		polls := make(map[string]int, len(pending))
+		batch := make([]niconico.Video, 0, len(pending)+len(videos))
+		for _, delivery := range pending {
+			polls[delivery.Video.ID] = delivery.Polls
+			batch = append(batch, delivery.Video)
+		}
+		batch = append(batch, videos...)
+		stats := sink.notify(ctx, batch)
+		next := make([]pendingDelivery, 0, len(stats.retry))
+		for _, video := range stats.retry {
+			attempts := polls[video.ID]
+			// A delivery cut short by cancellation has not had a fair try.
+			if ctx.Err() == nil {
+				attempts++
+			}
+			if attempts >= maxPendingPolls {
+				logger.Error("notification dropped after repeated failures", "sink", stats.name, "id", video.ID, "polls", attempts)
+				stats.dropped++
+				continue
+			}
+			next = append(next, pendingDelivery{Video: video, Polls: attempts})
+		}
+		seen.setPending(sink.key(), next)
+		if i := slices.IndexFunc(merged, func(s sinkStats) bool { return s.name == stats.name }); i >= 0 {
+			merged[i].ok += stats.ok
+			merged[i].failed += stats.failed
+			merged[i].dropped += stats.dropped
+			continue
+		}
+		stats.retry = nil
+		merged = append(merged, stats)
+	}
+	return merged
+}
+
+// sinkRetryPolicy is how many times, and how far apart, a sink attempts one delivery; it is independent of the fetch retries.
+type sinkRetryPolicy struct {
+	attempts int
+	wait     time.Duration
+}
+
+// sinkError is a failed delivery attempt; permanent errors are not retried, and retryAfter raises the next wait.
+type sinkError struct {
+	err        error
+	permanent  bool
+	retryAfter time.Duration
+}
+
+func (e *sinkError) Error() string { return e.err.Error() }
+
+func (e *sinkError) Unwrap() error { return e.err }
+
+// isPermanentSinkError reports whether err is a sinkError that no later attempt can fix.
+func isPermanentSinkError(err error) bool {
+	var sinkErr *sinkError
+	return errors.As(err, &sinkErr) && sinkErr.permanent
+}
+
+// do calls deliver until it succeeds, fails permanently, or runs out of attempts, doubling the wait after each failure.
+func (p sinkRetryPolicy) do(ctx context.Context, deliver func(ctx context.Context) error) error {
+	wait := p.wait
+	for attempt := 1; ; attempt++ {
+		err := deliver(ctx)
+		if err == nil {
+			return nil
+		}
+		if isPermanentSinkError(err) || attempt >= p.attempts {
+			return err
+		}
+		delay := wait
+		var sinkErr *sinkError
+		if errors.As(err, &sinkErr) && sinkErr.retryAfter > delay {
+			delay = sinkErr.retryAfter
+		}
+		if !sleepContext(ctx, delay) {
+			return err
+		}
+		wait *= 2
+	}
+}
+
+// validateNotifyFlags checks the watch notification flags.
+func validateNotifyFlags(cfg *RootConfig) error {
+	for _, target := range cfg.Webhooks {
+		parsed, err := url.Parse(target)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("webhook must be an http or https URL: %s", target)
+		}
+	}
+	if !slices.Contains(webhookFormats, cfg.WebhookFormat) {
+		return fmt.Errorf("webhook-format must be one of %s", strings.Join(webhookFormats, ", "))
+	}
+	if cfg.WebhookRetries < 1 {
+		return errors.New("webhook-retries must be at least 1")
+	}
+	if cfg.WebhookRetryWait < 0 {
+		return errors.New("webhook-retry-wait must be at least 0")
+	}
+	if cfg.ExecCommand != "" {
+		if _, err := splitCommandLine(cfg.ExecCommand); err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+	}
+	if cfg.ExecConcurrency < 1 {
+		return errors.New("exec-concurrency must be at least 1")
+	}
+	if cfg.ExecRetries < 1 {
+		return errors.New("exec-retries must be at least 1")
+	}
+	if cfg.ExecRetryWait < 0 {
+		return errors.New("exec-retry-wait must be at least 0")
+	}
+	if cfg.ExecTimeout <= 0 {
+		return errors.New("exec-timeout must be greater than 0")
+	}
+	return nil
+}
+
+// newNotifySinks builds the sinks configured by --webhook and --exec, one per webhook URL; flags must already be validated.
+func newNotifySinks(cfg *RootConfig, runLogger *slog.Logger) []notifySink {
+	var sinks []notifySink
+	client := &http.Client{Timeout: cfg.HTTPClientTimeout}
+	for _, target := range cfg.Webhooks {
+		sinks = append(sinks, &webhookSink{
+			url:    target,
+			format: cfg.WebhookFormat,
+			batch:  cfg.WebhookBatch,
+			client: client,
+			retry:  sinkRetryPolicy{attempts: cfg.WebhookRetries, wait: cfg.WebhookRetryWait},
+			logger: runLogger,
+		})
+	}
+	if cfg.ExecCommand != "" {
+		args, _ := splitCommandLine(cfg.ExecCommand)
+		sinks = append(sinks, &execSink{
+			args:        args,
+			concurrency: cfg.ExecConcurrency,
+			timeout:     cfg.ExecTimeout,
+			retry:       sinkRetryPolicy{attempts: cfg.ExecRetries, wait: cfg.ExecRetryWait},
+			logger:      runLogger,
+		})
+	}
+	return sinks
+}
+
+// webhookSink POSTs JSON payloads to one --webhook URL.
+type webhookSink struct {
+	url    string
+	format string
+	batch  bool
+	client *http.Client
+	retry  sinkRetryPolicy
+	logger *slog.Logger
+}
+
+// key hashes the URL, so the seen file does not store the token a webhook URL often carries.
+func (s *webhookSink) key() string {
+	sum := sha256.Sum256([]byte(s.url))
+	return "webhook/" + hex.EncodeToString(sum[:8])
+}
+
+func (s *webhookSink) notify(ctx context.Context, videos []niconico.Video) sinkStats {
+	stats := sinkStats{name: "webhook"}
+	if len(videos) == 0 {
+		return stats
+	}
+	for _, group := range webhookGroups(s.format, s.batch, videos) {
+		payload, err := webhookPayload(s.format, s.batch, group)
+		if err != nil {
+			err = &sinkError{err: err, permanent: true}
+		} else {
+			err = s.retry.do(ctx, func(ctx context.Context) error { return s.post(ctx, s.url, payload) })
+		}
+		if err != nil {
+			s.logger.Error("webhook delivery failed", "url", s.url, "error", err)
+			stats.failed++
+			if isPermanentSinkError(err) {
+				stats.dropped += len(group)
+			} else {
+				stats.retry = append(stats.retry, group...)
+			}
+			continue
+		}
+		stats.ok++
+	}
+	return stats
+}
+
+// post sends one payload; 429 and 5xx responses and transport errors are retryable, other non-2xx responses are not.
+func (s *webhookSink) post(ctx context.Context, target string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return &sinkError{err: err, permanent: true}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return &sinkError{err: err}
+	}
+	defer func() { _ = res.Body.Close() }()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	return &sinkError{
+		err:        fmt.Errorf("webhook %s: status %d", target, res.StatusCode),
+		permanent:  res.StatusCode != http.StatusTooManyRequests && res.StatusCode < 500,
+		retryAfter: webhookRetryAfter(res),
+	}
+}
+
+// webhookRetryAfter returns the Retry-After delay in seconds or as an HTTP date, or 0.
+func webhookRetryAfter(res *http.Response) time.Duration {
+	value := strings.TrimSpace(res.Header.Get("Retry-After"))
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if parsed, err := http.ParseTime(value); err == nil {
+		return max(time.Until(parsed), 0)
+	}
+	return 0
+}
+
+// webhookGroups splits videos into the groups posted together: one per video, or one per batch (per 10 videos for Discord).
+func webhookGroups(format string, batch bool, videos []niconico.Video) [][]niconico.Video {
+	switch {
+	case !batch:
+		groups := make([][]niconico.Video, 0, len(videos))
+		for i := range videos {
+			groups = append(groups, videos[i:i+1])
+		}
+		return groups
+	case format == webhookFormatDiscord:
+		return slices.Collect(slices.Chunk(videos, discordMaxEmbeds))
+	default:
+		return [][]niconico.Video{videos}
+	}
+}
+
+// webhookPayload returns the request body for one group of videos.
+func webhookPayload(format string, batch bool, group []niconico.Video) ([]byte, error) {
+	var body any
+	switch {
+	case format == webhookFormatDiscord:
+		embeds := make([]discordEmbed, 0, len(group))
+		for _, video := range group {
+			embeds = append(embeds, newDiscordEmbed(video))
+		}
+		body = discordPayload{Embeds: embeds}
+	case format == webhookFormatSlack:
+		lines := make([]string, 0, len(group))
+		for _, video := range group {
+			lines = append(lines, slackVideoLine(video))
+		}
+		body = slackPayload{Text: strings.Join(lines, "\n")}
+	case batch:
+		body = webhookBatchPayload{Count: len(group), Videos: group}
+	default:
+		body = group[0]
+	}
+	return json.Marshal(body)
+}
+
+// webhookBatchPayload is the generic --webhook-batch body.
+type webhookBatchPayload struct {
+	Count  int              `json:"count"`
+	Videos []niconico.Video `json:"videos"`
+}
+
+// discordPayload is a Discord webhook message with one embed per video.
+type discordPayload struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+// discordEmbed is one video in a Discord message.
+type discordEmbed struct {
+	Title     string            `json:"title"`
+	URL       string            `json:"url"`
+	Timestamp string            `json:"timestamp,omitempty"`
+	Author    *discordAuthor    `json:"author,omitempty"`
+	Thumbnail *discordThumbnail `json:"thumbnail,omitempty"`
+}
+
+// discordAuthor is the uploader shown above an embed title.
+type discordAuthor struct {
+	Name string `json:"name"`
+}
+
+// discordThumbnail is the image shown beside an embed.
+type discordThumbnail struct {
+	URL string `json:"url"`
+}
+
+// newDiscordEmbed builds the embed for a video, falling back to its ID when the title is unknown.
+func newDiscordEmbed(video niconico.Video) discordEmbed {
+	embed := discordEmbed{Title: video.Title, URL: watchURL(video.ID)}
+	if embed.Title == "" {
+		embed.Title = video.ID
+	}
+	if !video.RegisteredAt.IsZero() {
+		embed.Timestamp = video.RegisteredAt.UTC().Format(time.RFC3339)
+	}
+	if video.Owner.Name != "" {
+		embed.Author = &discordAuthor{Name: video.Owner.Name}
+	}
+	if video.Thumbnail.URL != "" {
+		embed.Thumbnail = &discordThumbnail{URL: video.Thumbnail.URL}
+	}
+	return embed
+}
+
+// slackPayload is a Slack incoming webhook message.
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// slackEscaper escapes the characters Slack treats as control sequences in message text.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackVideoLine formats a video as a Slack link labeled with its title.
+func slackVideoLine(video niconico.Video) string {
+	label := video.Title
+	if label == "" {
+		label = video.ID
+	}
+	return "<" + watchURL(video.ID) + "|" + slackEscaper.Replace(label) + ">"
+}
+
+// execSink runs the --exec command once per video, without a shell.
+type execSink struct {
+	args        []string
+	concurrency int
+	timeout     time.Duration
+	retry       sinkRetryPolicy
+	logger      *slog.Logger
+}
+
+func (s *execSink) key() string { return "exec" }
+
+func (s *execSink) notify(ctx context.Context, videos []niconico.Video) sinkStats {
+	stats := sinkStats{name: "exec"}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.concurrency)
+	for _, video := range videos {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(video niconico.Video) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := s.retry.do(ctx, func(ctx context.Context) error { return s.run(ctx, video) })
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				s.logger.Error("exec failed", "id", video.ID, "error", err)
+				stats.failed++
+				if isPermanentSinkError(err) {
+					stats.dropped++
+				} else {
+					stats.retry = append(stats.retry, video)
+				}
+				return
+			}
+			stats.ok++
+		}(video)
+	}
+	wg.Wait()
+	return stats
+}
+
+// run executes the command for one video with {id}, {url}, and {title} replaced and the video JSON on stdin.
+func (s *execSink) run(ctx context.Context, video niconico.Video) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	replacer := strings.NewReplacer("{id}", video.ID, "{url}", watchURL(video.ID), "{title}", video.Title)
+	args := make([]string, 0, len(s.args))
+	for _, arg := range s.args {
+		args = append(args, replacer.Replace(arg))
+	}
+	input, err := json.Marshal(video)
+	if err != nil {
+		return &sinkError{err: err, permanent: true}
+	}
+	command := exec.CommandContext(ctx, args[0], args[1:]...)
+	command.Stdin = bytes.NewReader(append(input, '\n'))
+	output, err := command.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return &sinkError{err: fmt.Errorf("exec %s: %w", video.ID, err), permanent: true}
+	}
+	message := strings.TrimSpace(string(output))
+	if len(message) > execOutputLimit {
+		message = message[:execOutputLimit] + "..."
+	}
+	if message == "" {
+		return fmt.Errorf("exec %s: %w", video.ID, err)
+	}
+	return fmt.Errorf("exec %s: %w: %s", video.ID, err, message)
+}
+
+// splitCommandLine splits an --exec command into arguments on whitespace.
+// Single quotes keep their content literally; inside double quotes, \" and \\ are escapes; other backslashes are literal.
+func splitCommandLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+				i++
+				current.WriteRune(runes[i])
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("command is empty")
+	}
+	return args, nil
+}
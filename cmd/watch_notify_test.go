@@ -0,0 +1,430 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+// TestExecSinkHelperProcess is run as the --exec command by the tests below; it records its arguments and stdin video ID.
+func TestExecSinkHelperProcess(t *testing.T) {
+	if os.Getenv("GO_NICO_LIST_EXEC_HELPER") == "" {
+		return
+	}
+	args := os.Args
+	for i, arg := range args {
+		if arg == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+	var video niconico.Video
+	if err := json.NewDecoder(os.Stdin).Decode(&video); err != nil {
+		fmt.Fprintln(os.Stderr, "bad stdin:", err)
+		os.Exit(2)
+	}
+	file, err := os.OpenFile(os.Getenv("GO_NICO_LIST_EXEC_OUT"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		os.Exit(2)
+	}
+	_, _ = fmt.Fprintf(file, "%s stdin=%s\n", strings.Join(args, " "), video.ID)
+	_ = file.Close()
+	if os.Getenv("GO_NICO_LIST_EXEC_FAIL") != "" {
+		fmt.Fprintln(os.Stderr, "refused")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// execHelperCommand returns an --exec command that runs TestExecSinkHelperProcess and the file it records into.
+func execHelperCommand(t *testing.T, fail bool) (string, string) {
+	t.Helper()
+	outPath := filepath.Join(t.TempDir(), "exec.txt")
+	t.Setenv("GO_NICO_LIST_EXEC_HELPER", "1")
+	t.Setenv("GO_NICO_LIST_EXEC_OUT", outPath)
+	if fail {
+		t.Setenv("GO_NICO_LIST_EXEC_FAIL", "1")
+	}
+	return fmt.Sprintf("'%s' -test.run=^TestExecSinkHelperProcess$ -- {id} \"{url}\"", os.Args[0]), outPath
+}
+
+func readExecLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read exec output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	slices.Sort(lines)
+	return lines
+}
+
+// webhookTestPayloads returns the request bodies a webhook sink posts for videos.
+func webhookTestPayloads(t *testing.T, format string, batch bool, videos []niconico.Video) [][]byte {
+	t.Helper()
+	var payloads [][]byte
+	for _, group := range webhookGroups(format, batch, videos) {
+		payload, err := webhookPayload(format, batch, group)
+		if err != nil {
+			t.Fatalf("build payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+func TestWebhookPayloadShapes(t *testing.T) {
+	videos := formatTestVideos()
+	videos[1].Title = "a <b> & c"
+
+	payloads := webhookTestPayloads(t, webhookFormatGeneric, false, videos)
+	if len(payloads) != 2 {
+		t.Fatalf("unexpected generic payloads: %d", len(payloads))
+	}
+	var single niconico.Video
+	if err := json.Unmarshal(payloads[1], &single); err != nil || single.ID != "sm2" {
+		t.Fatalf("unexpected generic payload: %s", payloads[1])
+	}
+
+	payloads = webhookTestPayloads(t, webhookFormatGeneric, true, videos)
+	var batch webhookBatchPayload
+	if err := json.Unmarshal(payloads[0], &batch); err != nil || len(payloads) != 1 || batch.Count != 2 || batch.Videos[0].ID != "sm1" {
+		t.Fatalf("unexpected batch payload: %s", payloads[0])
+	}
+
+	payloads = webhookTestPayloads(t, webhookFormatSlack, true, videos)
+	if got := string(payloads[0]); got != `{"text":"\u003chttps://www.nicovideo.jp/watch/sm1|title, with \"quotes\"\u003e\n\u003chttps://www.nicovideo.jp/watch/sm2|a \u0026lt;b\u0026gt; \u0026amp; c\u003e"}` {
+		t.Fatalf("unexpected slack payload: %s", got)
+	}
+
+	many := make([]niconico.Video, 0, 12)
+	for i := 1; i <= 12; i++ {
+		many = append(many, niconico.Video{ID: fmt.Sprintf("sm%d", i)})
+	}
+	payloads = webhookTestPayloads(t, webhookFormatDiscord, true, many)
+	if len(payloads) != 2 {
+		t.Fatalf("expected Discord batches of %d, got %d payloads", discordMaxEmbeds, len(payloads))
+	}
+	payloads = webhookTestPayloads(t, webhookFormatDiscord, false, videos[:1])
+	if got := string(payloads[0]); got != `{"embeds":[{"title":"title, with \"quotes\"","url":"https://www.nicovideo.jp/watch/sm1","timestamp":"2024-01-02T03:04:05Z","author":{"name":"owner"}}]}` {
+		t.Fatalf("unexpected discord payload: %s", got)
+	}
+}
+
+func TestWebhookSinkRetryPolicy(t *testing.T) {
+	var flaky, rejected atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if flaky.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/rejected":
+			rejected.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	newSink := func(path string) *webhookSink {
+		return &webhookSink{
+			url:    server.URL + path,
+			format: webhookFormatGeneric,
+			batch:  true,
+			client: server.Client(),
+			retry:  sinkRetryPolicy{attempts: 3, wait: time.Millisecond},
+			logger: slog.New(slog.DiscardHandler),
+		}
+	}
+	videos := formatTestVideos()
+	if stats := newSink("/flaky").notify(context.Background(), videos); stats.String() != "webhook_ok=1 webhook_err=0 webhook_dropped=0" {
+		t.Fatalf("unexpected flaky stats: %s", stats)
+	}
+	// A 4xx response cannot succeed later, so its videos are dropped instead of retried.
+	stats := newSink("/rejected").notify(context.Background(), videos)
+	if stats.String() != fmt.Sprintf("webhook_ok=0 webhook_err=1 webhook_dropped=%d", len(videos)) || len(stats.retry) != 0 {
+		t.Fatalf("unexpected rejected stats: %s %v", stats, stats.retry)
+	}
+	// 5xx is retried; other 4xx responses fail immediately.
+	if flaky.Load() != 2 || rejected.Load() != 1 {
+		t.Fatalf("unexpected attempts: flaky=%d rejected=%d", flaky.Load(), rejected.Load())
+	}
+	if stats := newSink("/flaky").notify(context.Background(), nil); stats.ok != 0 || stats.failed != 0 {
+		t.Fatalf("empty poll should not post: %+v", stats)
+	}
+}
+
+func TestWatchNotifiesWebhookAndExec(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []webhookBatchPayload
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookBatchPayload
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &payload); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		bodies = append(bodies, payload)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+	command, execOut := execHelperCommand(t, false)
+	server, _ := newWatchTestServer(t, 2)
+	_, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), watchTestArgs(
+		"--polls", "1", "--emit-initial",
+		"--webhook", receiver.URL, "--webhook-batch",
+		"--exec", command, "--exec-concurrency", "2",
+		"nicovideo.jp/user/1",
+	)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(errOut.String(), "output_count=2 poll=1 webhook_ok=1 webhook_err=0 webhook_dropped=0 exec_ok=2 exec_err=0 exec_dropped=0\n") {
+		t.Fatalf("unexpected summary: %q", errOut.String())
+	}
+	if len(bodies) != 1 || bodies[0].Count != 2 || bodies[0].Videos[1].ID != "sm2" {
+		t.Fatalf("unexpected webhook bodies: %+v", bodies)
+	}
+	want := []string{
+		"sm1 https://www.nicovideo.jp/watch/sm1 stdin=sm1",
+		"sm2 https://www.nicovideo.jp/watch/sm2 stdin=sm2",
+	}
+	if got := readExecLines(t, execOut); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected exec runs: %q", got)
+	}
+}
+
+func TestWatchReportsExecFailuresAfterRetries(t *testing.T) {
+	command, execOut := execHelperCommand(t, true)
+	server, _ := newWatchTestServer(t, 1)
+	_, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), watchTestArgs(
+		"--polls", "1", "--emit-initial", "--exec", command, "--exec-retries", "2", "--exec-retry-wait", "1ms", "nicovideo.jp/user/1",
+	)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(errOut.String(), "poll=1 exec_ok=0 exec_err=1 exec_dropped=0\n") {
+		t.Fatalf("unexpected summary: %q", errOut.String())
+	}
+	if got := readExecLines(t, execOut); len(got) != 2 {
+		t.Fatalf("expected 2 attempts, got %q", got)
+	}
+}
+
+func TestWatchRedeliversFailedWebhookVideos(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var mu sync.Mutex
+	var delivered []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var video niconico.Video
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &video); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		delivered = append(delivered, video.ID)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+	server, _ := newWatchTestServer(t, 1)
+	seenFile := filepath.Join(t.TempDir(), "seen.json")
+	args := func(polls string) []string {
+		return watchTestArgs("--polls", polls, "--emit-initial", "--seen-file", seenFile, "--webhook", receiver.URL, "--webhook-retries", "1", "nicovideo.jp/user/1")
+	}
+
+	// Every delivery fails, so sm1 is kept pending in the seen file.
+	_, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), args("1")...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(errOut.String(), "poll=1 webhook_ok=0 webhook_err=1 webhook_dropped=0\n") {
+		t.Fatalf("unexpected summary: %q", errOut.String())
+	}
+	seen, err := loadSeenVideos(seenFile)
+	if err != nil {
+		t.Fatalf("load seen file: %v", err)
+	}
+	key := (&webhookSink{url: receiver.URL}).key()
+	if pending := seen.pendingFor(key); len(pending) != 1 || pending[0].Video.ID != "sm1" || pending[0].Polls != 1 {
+		t.Fatalf("expected sm1 to be pending, got %+v", seen.pending)
+	}
+
+	// After a restart sm1 is delivered with the newer sm2; the second poll only delivers sm3.
+	failing.Store(false)
+	out, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), args("2")...)
+	if err != nil {
+		t.Fatalf("unexpected error after restart: %v", err)
+	}
+	if got := out.String(); got != "sm2\nsm3\n" {
+		t.Fatalf("pending deliveries should not be printed again: %q", got)
+	}
+	for _, want := range []string{"poll=1 webhook_ok=2 webhook_err=0 webhook_dropped=0\n", "poll=2 webhook_ok=1 webhook_err=0 webhook_dropped=0\n"} {
+		if !strings.Contains(errOut.String(), want) {
+			t.Fatalf("missing %q in stderr: %q", want, errOut.String())
+		}
+	}
+	if want := []string{"sm1", "sm2", "sm3"}; !reflect.DeepEqual(delivered, want) {
+		t.Fatalf("expected deliveries %v, got %v", want, delivered)
+	}
+}
+
+func TestWatchRedeliversOnNextPoll(t *testing.T) {
+	var posts atomic.Int64
+	var mu sync.Mutex
+	delivered := make(map[string][]string)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flaky" && posts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var video niconico.Video
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &video)
+		mu.Lock()
+		delivered[r.URL.Path] = append(delivered[r.URL.Path], video.ID)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+	server, _ := newWatchTestServer(t, 1)
+	out, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(), watchTestArgs(
+		"--polls", "2", "--emit-initial", "--webhook", receiver.URL+"/flaky", "--webhook", receiver.URL+"/stable", "--webhook-retries", "1", "nicovideo.jp/user/1",
+	)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// sm1 is posted again with sm2 only to the URL that failed, and it is printed once.
+	if got := out.String(); got != "sm1\nsm2\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	want := map[string][]string{"/flaky": {"sm1", "sm2"}, "/stable": {"sm1", "sm2"}}
+	if !reflect.DeepEqual(delivered, want) {
+		t.Fatalf("expected deliveries %v, got %v", want, delivered)
+	}
+	if !strings.Contains(errOut.String(), "poll=1 webhook_ok=1 webhook_err=1 webhook_dropped=0\n") {
+		t.Fatalf("unexpected summary: %q", errOut.String())
+	}
+}
+
+func TestWatchDropsPermanentWebhookFailures(t *testing.T) {
+	var posts atomic.Int64
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer receiver.Close()
+	server, _ := newWatchTestServer(t, 1)
+	_, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(),
+		watchTestArgs("--polls", "2", "--emit-initial", "--webhook", receiver.URL, "nicovideo.jp/user/1")...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// sm1 is not posted again by the second poll, which only posts sm2.
+	if posts.Load() != 2 {
+		t.Fatalf("expected 2 posts, got %d", posts.Load())
+	}
+	for _, want := range []string{"poll=1 webhook_ok=0 webhook_err=1 webhook_dropped=1\n", "poll=2 webhook_ok=0 webhook_err=1 webhook_dropped=1\n"} {
+		if !strings.Contains(errOut.String(), want) {
+			t.Fatalf("missing %q in stderr: %q", want, errOut.String())
+		}
+	}
+}
+
+// retryingSink fails every delivery with a retryable error.
+type retryingSink struct{}
+
+func (retryingSink) key() string { return "retrying" }
+
+func (retryingSink) notify(ctx context.Context, videos []niconico.Video) sinkStats {
+	return sinkStats{name: "retrying", failed: len(videos), retry: videos}
+}
+
+func TestDeliverPendingDropsAfterMaxPolls(t *testing.T) {
+	seen, err := loadSeenVideos("")
+	if err != nil {
+		t.Fatalf("load seen: %v", err)
+	}
+	logger := slog.New(slog.DiscardHandler)
+	videos := formatTestVideos()[:1]
+	for poll := 1; poll <= maxPendingPolls; poll++ {
+		stats := deliverPending(context.Background(), []notifySink{retryingSink{}}, seen, videos, logger)
+		videos = nil
+		wantDropped := 0
+		if poll == maxPendingPolls {
+			wantDropped = 1
+		}
+		if len(stats) != 1 || stats[0].failed != 1 || stats[0].dropped != wantDropped {
+			t.Fatalf("poll %d: unexpected stats %+v", poll, stats)
+		}
+	}
+	if pending := seen.pendingFor("retrying"); len(pending) != 0 {
+		t.Fatalf("expected no pending deliveries, got %+v", pending)
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+		err  string
+	}{
+		{line: `notify-send "New: {title}" {url}`, want: []string{"notify-send", "New: {title}", "{url}"}},
+		{line: `sh -c 'echo "$1"' _ {id}`, want: []string{"sh", "-c", `echo "$1"`, "_", "{id}"}},
+		{line: `C:\tools\hook.exe "a \"b\" \\ c" ''`, want: []string{`C:\tools\hook.exe`, `a "b" \ c`, ""}},
+		{line: `echo 'open`, err: "unterminated quote"},
+		{line: "  ", err: "command is empty"},
+	}
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.line)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("splitCommandLine(%q) error = %v, want %s", tt.line, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("splitCommandLine(%q) = %q, %v", tt.line, got, err)
+		}
+	}
+}
+
+func TestNotifyFlagValidation(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--webhook", "ftp://example.com"}, want: "webhook must be an http or https URL: ftp://example.com"},
+		{args: []string{"--webhook-format", "teams"}, want: "webhook-format must be one of generic, discord, slack"},
+		{args: []string{"--webhook-retries", "-1"}, want: "webhook-retries must be at least 1"},
+		{args: []string{"--exec", `echo "{id}`}, want: "exec: unterminated quote"},
+		{args: []string{"--exec-concurrency", "-1"}, want: "exec-concurrency must be at least 1"},
+		{args: []string{"--exec-timeout", "-1s"}, want: "exec-timeout must be greater than 0"},
+	}
+	for _, tt := range tests {
+		args := append(append([]string{"watch"}, tt.args...), "nicovideo.jp/user/1")
+		_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), args...)
+		if err == nil || err.Error() != tt.want {
+			t.Fatalf("unexpected error for %v: %v", tt.args, err)
+		}
+	}
+}
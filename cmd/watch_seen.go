@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	"github.com/sh4869221b/go-nico-list/niconico"
+)
+
+const seenFileVersion = 1
+
+// seenFilePayload is the on-disk layout of --seen-file.
+type seenFilePayload struct {
+	Version int                          `json:"version"`
+	Targets map[string][]string          `json:"targets"`
+	Pending map[string][]pendingDelivery `json:"pending,omitempty"`
+}
+
+// pendingDelivery is a video a notification sink has not delivered yet and how many polls have tried it.
+type pendingDelivery struct {
+	Video niconico.Video `json:"video"`
+	Polls int            `json:"polls"`
+}
+
+// seenVideos holds the video IDs watch has recorded per target; a state without a path lives in memory only.
+type seenVideos struct {
+	path    string
+	changed bool
+	targets map[string]map[string]struct{}
+	// all is the union of every target's IDs, so a video listed by several targets is only reported once.
+	all map[string]struct{}
+	// pending holds each notification sink's undelivered videos by sink key.
+	pending map[string][]pendingDelivery
+}
+
+// loadSeenVideos reads the seen file at path, treating a missing file or an empty path as no targets seen.
+func loadSeenVideos(path string) (*seenVideos, error) {
+	seen := &seenVideos{
+		path:    path,
+		targets: make(map[string]map[string]struct{}),
+		all:     make(map[string]struct{}),
+		pending: make(map[string][]pendingDelivery),
+	}
+	if path == "" {
+		return seen, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return seen, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var payload seenFilePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("seen-file %s: %w", path, err)
+	}
+	if payload.Version != seenFileVersion {
+		return nil, fmt.Errorf("seen-file %s: unsupported version %d", path, payload.Version)
+	}
+	for key, ids := range payload.Targets {
+		set := make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			set[id] = struct{}{}
+			seen.all[id] = struct{}{}
+		}
+		seen.targets[key] = set
+	}
+	for key, deliveries := range payload.Pending {
+		seen.pending[key] = deliveries
+	}
+	return seen, nil
+}
+
+// observe records a successful fetch of target and returns the videos no target has listed before.
+// The first fetch of a target only records its videos unless emitInitial is set, so adding a target does not report its back catalog.
+func (s *seenVideos) observe(target inputTarget, videos []niconico.Video, emitInitial bool) []niconico.Video {
+	key := targetStateKey(target)
+	ids, known := s.targets[key]
+	if !known {
+		ids = make(map[string]struct{}, len(videos))
+		s.targets[key] = ids
+		s.changed = true
+	}
+	unseen := make([]niconico.Video, 0)
+	for _, video := range videos {
+		if _, ok := ids[video.ID]; ok {
+			continue
+		}
+		ids[video.ID] = struct{}{}
+		s.changed = true
+		if _, ok := s.all[video.ID]; ok {
+			continue
+		}
+		s.all[video.ID] = struct{}{}
+		if known || emitInitial {
+			unseen = append(unseen, video)
+		}
+	}
+	return unseen
+}
+
+// pendingFor returns the deliveries still pending for the sink with key.
+func (s *seenVideos) pendingFor(key string) []pendingDelivery {
+	return s.pending[key]
+}
+
+// setPending replaces the deliveries pending for the sink with key.
+func (s *seenVideos) setPending(key string, deliveries []pendingDelivery) {
+	if len(deliveries) == 0 && len(s.pending[key]) == 0 {
+		return
+	}
+	if len(deliveries) == 0 {
+		delete(s.pending, key)
+	} else {
+		s.pending[key] = deliveries
+	}
+	s.changed = true
+}
+
+// keepPending drops the pending deliveries of sinks whose key is not in keys, such as a removed --webhook URL.
+func (s *seenVideos) keepPending(keys []string) {
+	for key := range s.pending {
+		if !slices.Contains(keys, key) {
+			delete(s.pending, key)
+			s.changed = true
+		}
+	}
+}
+
+// save atomically replaces the seen file when observe recorded anything new.
+func (s *seenVideos) save() error {
+	if s.path == "" || !s.changed {
+		return nil
+	}
+	payload := seenFilePayload{Version: seenFileVersion, Targets: make(map[string][]string, len(s.targets)), Pending: s.pending}
+	for key, set := range s.targets {
+		ids := make([]string, 0, len(set))
+		for id := range set {
+			ids = append(ids, id)
+		}
+		niconico.NiconicoSort(ids)
+		payload.Targets[key] = ids
+	}
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomically(s.path, append(data, '\n')); err != nil {
+		return err
+	}
+	s.changed = false
+	return nil
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newWatchTestServer serves a user video list that gains one newer video (sm<n>) on each first-page request.
+func newWatchTestServer(t *testing.T, start int) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+			return
+		}
+		newest := start + int(requests.Add(1)) - 1
+		items := make([]string, 0, newest)
+		for id := newest; id >= 1; id-- {
+			items = append(items, `{"essential":{"id":"sm`+columnText(id)+`","registeredAt":"2024-01-01T00:00:00Z","count":{"comment":10}}}`)
+		}
+		_, _ = io.WriteString(w, `{"data":{"items":[`+strings.Join(items, ",")+`]}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func watchTestArgs(extra ...string) []string {
+	return append([]string{"watch", "--interval", "1ms", "--jitter", "0", "--rate-limit", "0"}, extra...)
+}
+
+func TestWatchPrintsOnlyUnseenVideosAcrossRestarts(t *testing.T) {
+	server, _ := newWatchTestServer(t, 1)
+	seenFile := filepath.Join(t.TempDir(), "seen.json")
+	out, errOut, err := executeTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps(),
+		watchTestArgs("--polls", "3", "--seen-file", seenFile, "--url", "nicovideo.jp/user/1")...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The first poll only records sm1; each later poll finds one newer video.
+	if got := out.String(); got != "https://www.nicovideo.jp/watch/sm2\nhttps://www.nicovideo.jp/watch/sm3\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	for _, want := range []string{"output_count=0 poll=1\n", "output_count=1 poll=2\n", "fetch_ok=1 fetch_err=0 output_count=1 poll=3\n"} {
+		if !strings.Contains(errOut.String(), want) {
+			t.Fatalf("missing %q in stderr: %q", want, errOut.String())
+		}
+	}
+	data, err := os.ReadFile(seenFile)
+	if err != nil {
+		t.Fatalf("read seen file: %v", err)
+	}
+	if got := string(data); got != "{\n  \"version\": 1,\n  \"targets\": {\n    \"user/1\": [\n      \"sm1\",\n      \"sm2\",\n      \"sm3\"\n    ]\n  }\n}\n" {
+		t.Fatalf("unexpected seen file:\n%s", got)
+	}
+
+	// A restarted watch knows the target, so its first poll reports what appeared while it was stopped.
+	restarted, _ := newWatchTestServer(t, 5)
+	out, _, err = executeTestRootCommand(t, testFetchConfig(restarted.URL), newTestRootDeps(),
+		watchTestArgs("--polls", "1", "--seen-file", seenFile, "nicovideo.jp/user/1")...)
+	if err != nil {
+		t.Fatalf("unexpected error after restart: %v", err)
+	}
+	if got := out.String(); got != "sm4\nsm5\n" {
+		t.Fatalf("unexpected output after restart: %q", got)
+	}
+}
+
+func TestWatchEmitInitialAndSharedVideos(t *testing.T) {
+	server, _ := newWatchTestServer(t, 2)
+	cfg := testFetchConfig(server.URL)
+	out, _, err := executeTestRootCommand(t, cfg, newTestRootDeps(),
+		watchTestArgs("--polls", "1", "--emit-initial", "--format", "csv", "--columns", "id", "nicovideo.jp/user/1", "nicovideo.jp/user/2")...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Both users list sm1 and sm2 (the second also sm3), but each video is reported once.
+	if got := out.String(); got != "id\nsm1\nsm2\nsm3\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
+
+func TestWatchStopsWhenContextIsCanceled(t *testing.T) {
+	server, requests := newWatchTestServer(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd, _, _ := newTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps())
+	cmd.SetContext(ctx)
+	cmd.SetArgs([]string{"watch", "--interval", "1h", "--rate-limit", "0", "--format", "m3u", "nicovideo.jp/user/1"})
+	done := make(chan error, 1)
+	go func() { done <- cmd.Execute() }()
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("watch did not poll")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop after cancel")
+	}
+}
+
+func TestWatchDoesNotRecordInterruptedPoll(t *testing.T) {
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	seenFile := filepath.Join(t.TempDir(), "seen.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd, _, errOut := newTestRootCommand(t, testFetchConfig(server.URL), newTestRootDeps())
+	cmd.SetContext(ctx)
+	cmd.SetArgs(watchTestArgs("--seen-file", seenFile, "nicovideo.jp/user/1"))
+	done := make(chan error, 1)
+	go func() { done <- cmd.Execute() }()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not poll")
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop after cancel")
+	}
+	// Recording user/1 with no videos would make the next start report its whole back catalog.
+	if _, err := os.Stat(seenFile); !os.IsNotExist(err) {
+		t.Fatalf("seen file written for interrupted poll: %v", err)
+	}
+	if !strings.Contains(errOut.String(), "fetch_ok=0 fetch_err=0 output_count=0 poll=1\n") {
+		t.Fatalf("unexpected stderr: %q", errOut.String())
+	}
+}
+
+func TestWatchValidation(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"watch", "--interval", "0"}, want: "interval must be greater than 0"},
+		{args: []string{"watch", "--jitter", "-1s"}, want: "jitter must be at least 0"},
+		{args: []string{"watch", "--polls", "-1"}, want: "polls must be at least 0"},
+		{args: []string{"watch", "--json"}, want: "watch does not support format json"},
+		{args: []string{"watch", "--format", "rss"}, want: "watch does not support format rss"},
+		{args: []string{"watch", "--stdin"}, want: "stdin cannot be combined with watch"},
+		{args: []string{"watch", "--state-file", "state.json"}, want: "state-file cannot be combined with watch"},
+		{args: []string{"watch", "--concurrency", "0"}, want: "concurrency must be at least 1"},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "nicovideo.jp/user/1")
+		_, _, err := executeTestRootCommand(t, newTestRootConfig(), newTestRootDeps(), args...)
+		if err == nil || err.Error() != tt.want {
+			t.Fatalf("unexpected error for %v: %v", tt.args, err)
+		}
+	}
+}
+
+func TestWatchDelayAddsJitterWithinBound(t *testing.T) {
+	if got := watchDelay(time.Minute, 0); got != time.Minute {
+		t.Fatalf("unexpected delay without jitter: %v", got)
+	}
+	for range 100 {
+		if got := watchDelay(time.Minute, time.Second); got < time.Minute || got >= time.Minute+time.Second {
+			t.Fatalf("delay out of range: %v", got)
+		}
+	}
+}
@@ -71,7 +71,7 @@ func BenchmarkGetVideoListLargeUserPayload(b *testing.B) {
 
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
-		ids, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 1, logger)
+		ids, err := newTestClient(server.URL, 1, 1, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 		if err != nil {
 			b.Fatalf("GetVideoList returned error: %v", err)
 		}
@@ -98,7 +98,7 @@ func BenchmarkGetMylistVideoListLargePayload(b *testing.B) {
 
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
-		ids, err := GetMylistVideoList(context.Background(), "847130", 0, after, before, server.URL, 1, time.Second, nil, 1, logger)
+		ids, err := newTestClient(server.URL, 1, 1, logger).GetMylistVideoList(context.Background(), "847130", Filter{CommentCount: 0, After: after, Before: before})
 		if err != nil {
 			b.Fatalf("GetMylistVideoList returned error: %v", err)
 		}
@@ -0,0 +1,216 @@
+package niconico
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const cacheFileSuffix = ".json"
+
+// DiskCache stores successful GET response bodies on disk keyed by request URL.
+type DiskCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// CacheEntryInfo describes one cached response.
+type CacheEntryInfo struct {
+	URL      string
+	StoredAt time.Time
+	Size     int64
+	Fresh    bool
+}
+
+// cacheEntry is the on-disk layout of a cached response.
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	StoredAt     time.Time `json:"stored_at"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Body         []byte    `json:"body"`
+}
+
+// NewDiskCache returns a cache rooted at dir whose entries are served without revalidation for ttl.
+func NewDiskCache(dir string, ttl time.Duration) *DiskCache {
+	return &DiskCache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// Dir returns the cache directory.
+func (d *DiskCache) Dir() string {
+	return d.dir
+}
+
+// Entries lists the cached responses ordered by URL; a missing directory has no entries.
+func (d *DiskCache) Entries() ([]CacheEntryInfo, error) {
+	paths, err := d.entryPaths()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]CacheEntryInfo, 0, len(paths))
+	for _, path := range paths {
+		entry, err := readCacheEntry(path)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, CacheEntryInfo{
+			URL:      entry.URL,
+			StoredAt: entry.StoredAt,
+			Size:     int64(len(entry.Body)),
+			Fresh:    d.fresh(entry),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].URL < infos[j].URL })
+	return infos, nil
+}
+
+// Prune removes entries older than the TTL and unreadable entries, returning the number removed.
+func (d *DiskCache) Prune() (int, error) {
+	return d.removeEntries(func(path string) bool {
+		entry, err := readCacheEntry(path)
+		return err != nil || !d.fresh(entry)
+	})
+}
+
+// Clear removes every entry, returning the number removed.
+func (d *DiskCache) Clear() (int, error) {
+	return d.removeEntries(func(string) bool { return true })
+}
+
+func (d *DiskCache) removeEntries(shouldRemove func(path string) bool) (int, error) {
+	paths, err := d.entryPaths()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, path := range paths {
+		if !shouldRemove(path) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (d *DiskCache) entryPaths() ([]string, error) {
+	dirEntries, err := os.ReadDir(d.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), cacheFileSuffix) {
+			continue
+		}
+		paths = append(paths, filepath.Join(d.dir, dirEntry.Name()))
+	}
+	return paths, nil
+}
+
+// fresh reports whether entry can be served without contacting the server.
+func (d *DiskCache) fresh(entry cacheEntry) bool {
+	return d.now().Sub(entry.StoredAt) < d.ttl
+}
+
+// pathFor returns the entry file for url.
+func (d *DiskCache) pathFor(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+cacheFileSuffix)
+}
+
+// lookup returns the cached entry for url, if any.
+func (d *DiskCache) lookup(url string) (cacheEntry, bool) {
+	entry, err := readCacheEntry(d.pathFor(url))
+	if err != nil || entry.URL != url {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store saves body for url along with the response validators.
+func (d *DiskCache) store(url string, header http.Header, body []byte) error {
+	return d.write(cacheEntry{
+		URL:          url,
+		StoredAt:     d.now(),
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Body:         body,
+	})
+}
+
+// refresh restarts the TTL of a revalidated entry.
+func (d *DiskCache) refresh(entry cacheEntry) error {
+	entry.StoredAt = d.now()
+	return d.write(entry)
+}
+
+// conditionalHeader returns the revalidation headers for entry, or nil when it has no validators.
+func conditionalHeader(entry cacheEntry) http.Header {
+	if entry.ETag == "" && entry.LastModified == "" {
+		return nil
+	}
+	header := http.Header{}
+	if entry.ETag != "" {
+		header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		header.Set("If-Modified-Since", entry.LastModified)
+	}
+	return header
+}
+
+func (d *DiskCache) write(entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+	path := d.pathFor(entry.URL)
+	tmp, err := os.CreateTemp(d.dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func readCacheEntry(path string) (cacheEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cacheEntry{}, err
+	}
+	return entry, nil
+}
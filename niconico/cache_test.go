@@ -0,0 +1,118 @@
+package niconico
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCachedPageServer(t *testing.T, requests *atomic.Int32, revalidated *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		etag := `"page-` + r.URL.Query().Get("page") + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			revalidated.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[{"essential":{"id":"sm1","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}}}]}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDiskCacheServesFreshEntriesWithoutRequests(t *testing.T) {
+	var requests, revalidated atomic.Int32
+	server := newCachedPageServer(t, &requests, &revalidated)
+	cache := NewDiskCache(t.TempDir(), time.Hour)
+	client := NewClient(WithBaseURL(server.URL), WithRetries(1), WithCache(cache), WithLogger(slog.New(slog.DiscardHandler)))
+
+	for range 2 {
+		got, err := client.GetVideoList(context.Background(), "1", Filter{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(VideoIDs(got), []string{"sm1"}) {
+			t.Fatalf("unexpected ids: %v", VideoIDs(got))
+		}
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expected only the first run to reach the server, got %d requests", requests.Load())
+	}
+}
+
+func TestDiskCacheRevalidatesStaleEntries(t *testing.T) {
+	var requests, revalidated atomic.Int32
+	server := newCachedPageServer(t, &requests, &revalidated)
+	cache := NewDiskCache(t.TempDir(), 0)
+	client := NewClient(WithBaseURL(server.URL), WithRetries(1), WithCache(cache), WithLogger(slog.New(slog.DiscardHandler)))
+
+	for range 2 {
+		got, err := client.GetVideoList(context.Background(), "1", Filter{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(VideoIDs(got), []string{"sm1"}) {
+			t.Fatalf("unexpected ids: %v", VideoIDs(got))
+		}
+	}
+	if requests.Load() != 4 || revalidated.Load() != 2 {
+		t.Fatalf("expected the second run to revalidate both pages, got requests=%d revalidated=%d", requests.Load(), revalidated.Load())
+	}
+}
+
+func TestDiskCacheEntriesPruneAndClear(t *testing.T) {
+	cache := NewDiskCache(t.TempDir(), time.Hour)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	if err := cache.store("https://example.com/old", http.Header{}, []byte("old")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if err := cache.store("https://example.com/new", http.Header{}, []byte("newer")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []CacheEntryInfo{
+		{URL: "https://example.com/new", StoredAt: now, Size: 5, Fresh: true},
+		{URL: "https://example.com/old", StoredAt: now.Add(-2 * time.Hour), Size: 3, Fresh: false},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	for i := range want {
+		if entries[i].URL != want[i].URL || !entries[i].StoredAt.Equal(want[i].StoredAt) || entries[i].Size != want[i].Size || entries[i].Fresh != want[i].Fresh {
+			t.Fatalf("unexpected entry %d: %+v", i, entries[i])
+		}
+	}
+
+	if removed, err := cache.Prune(); err != nil || removed != 1 {
+		t.Fatalf("expected prune to remove 1 entry, got %d (%v)", removed, err)
+	}
+	if _, ok := cache.lookup("https://example.com/new"); !ok {
+		t.Fatal("expected fresh entry to survive prune")
+	}
+	if removed, err := cache.Clear(); err != nil || removed != 1 {
+		t.Fatalf("expected clear to remove 1 entry, got %d (%v)", removed, err)
+	}
+	if entries, err := cache.Entries(); err != nil || len(entries) != 0 {
+		t.Fatalf("expected empty cache, got %+v (%v)", entries, err)
+	}
+}
@@ -0,0 +1,48 @@
+package niconico
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// GetChannelVideoList retrieves the videos uploaded to a channel, identified by "ch<digits>" or its screen name.
+func (c *Client) GetChannelVideoList(ctx context.Context, channelID string, filter Filter) ([]Video, error) {
+	return c.collectVideoList(ctx, filter,
+		func(page int) string {
+			return fmt.Sprintf("%s/channels/%s/videos?pageSize=%d&page=%d", c.baseURL, url.PathEscape(channelID), pageSize, page) + c.sortQuery()
+		},
+		parseChannelPage,
+		c.newestFirst(true),
+	)
+}
+
+func parseChannelPage(body []byte) (parsedPage, error) {
+	var payload struct {
+		Meta struct {
+			Status int `json:"status"`
+		} `json:"meta"`
+		Data struct {
+			TotalCount *int            `json:"totalCount"`
+			Items      []NicoEssential `json:"items"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return parsedPage{}, err
+	}
+	items := make([]Video, 0, len(payload.Data.Items))
+	for _, essential := range payload.Data.Items {
+		items = append(items, videoFromEssential(essential, NicoSeries{}))
+	}
+	totalCount := 0
+	if payload.Data.TotalCount != nil {
+		totalCount = *payload.Data.TotalCount
+	}
+	return parsedPage{
+		Items:           items,
+		Status:          payload.Meta.Status,
+		TotalCount:      totalCount,
+		TotalCountKnown: payload.Data.TotalCount != nil,
+	}, nil
+}
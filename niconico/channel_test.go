@@ -0,0 +1,75 @@
+package niconico
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetChannelVideoListPagesAndFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/channels/ch2632720/videos" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "1":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[{"id":"so1","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10},"isChannelVideo":true},{"id":"so2","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":1}}]}}`)
+		case "2":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[{"id":"so3","registeredAt":"2023-06-01T00:00:00Z","count":{"comment":10}},{"id":"so4","registeredAt":"2024-02-01T00:00:00Z","count":{"comment":10}}]}}`)
+		default:
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[]}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	filter := Filter{
+		CommentCount: 5,
+		After:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Before:       time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+	got, err := newTestClient(server.URL, 1, 1, slog.New(slog.DiscardHandler)).GetChannelVideoList(context.Background(), "ch2632720", filter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(VideoIDs(got), []string{"so1", "so4"}) {
+		t.Fatalf("unexpected ids: %v", VideoIDs(got))
+	}
+	if !got[0].IsChannelVideo {
+		t.Fatalf("expected channel video flag on %s", got[0].ID)
+	}
+}
+
+func TestGetChannelVideoListRetriesServerErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[]}}`)
+			return
+		}
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"totalCount":1,"items":[{"id":"so1","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}}]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	got, err := newTestClient(server.URL, 2, 1, slog.New(slog.DiscardHandler)).GetChannelVideoList(context.Background(), "nicovideo-official", Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(VideoIDs(got), []string{"so1"}) {
+		t.Fatalf("unexpected ids: %v", VideoIDs(got))
+	}
+	if attempts.Load() != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts.Load())
+	}
+}
@@ -0,0 +1,250 @@
+package niconico
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const pageSize = 100
+
+const (
+	// DefaultBaseURL is the nvapi base URL used when no base URL option is set.
+	DefaultBaseURL = "https://nvapi.nicovideo.jp/v3"
+	// DefaultRetries is the request attempt count used when no retries option is set.
+	DefaultRetries = 10
+	// DefaultTimeout is the HTTP client timeout used when no timeout option is set.
+	DefaultTimeout = 10 * time.Second
+	// DefaultSearchBaseURL is the snapshot search API endpoint used when no search base URL option is set.
+	DefaultSearchBaseURL = "https://snapshot.search.nicovideo.jp/api/v2/snapshot/video/contents/search"
+)
+
+// Client fetches video lists from the niconico API.
+type Client struct {
+	baseURL         string
+	searchBaseURL   string
+	retries         int
+	timeout         time.Duration
+	limiter         *RateLimiter
+	cache           *DiskCache
+	observer        Observer
+	logger          *slog.Logger
+	pageConcurrency int
+	sortKey         SortKey
+	sortOrder       SortOrder
+	transport       http.RoundTripper
+	httpClient      *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL sets the API base URL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) { c.baseURL = baseURL }
+}
+
+// WithSearchBaseURL sets the snapshot search API endpoint.
+func WithSearchBaseURL(searchBaseURL string) Option {
+	return func(c *Client) { c.searchBaseURL = searchBaseURL }
+}
+
+// WithRetries sets the number of attempts per request.
+func WithRetries(retries int) Option {
+	return func(c *Client) { c.retries = retries }
+}
+
+// WithTimeout sets the HTTP client timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.timeout = timeout }
+}
+
+// WithRateLimiter sets the rate limiter shared by all requests; nil disables rate limiting.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(c *Client) { c.limiter = limiter }
+}
+
+// WithCache sets the response cache for page and list requests; nil disables caching.
+func WithCache(cache *DiskCache) Option {
+	return func(c *Client) { c.cache = cache }
+}
+
+// WithLogger sets the logger; nil falls back to slog.Default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) { c.logger = logger }
+}
+
+// WithPageConcurrency sets the number of concurrent page requests per target.
+func WithPageConcurrency(pageConcurrency int) Option {
+	return func(c *Client) { c.pageConcurrency = pageConcurrency }
+}
+
+// WithTransport sets the HTTP transport; nil uses http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) { c.transport = transport }
+}
+
+// NewClient builds a Client from options, applying defaults for unset values.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		baseURL:         DefaultBaseURL,
+		searchBaseURL:   DefaultSearchBaseURL,
+		retries:         DefaultRetries,
+		timeout:         DefaultTimeout,
+		pageConcurrency: 1,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.logger == nil {
+		c.logger = slog.Default()
+	}
+	if c.observer == nil {
+		c.observer = nopObserver{}
+	}
+	if c.retries < 1 {
+		c.retries = 1
+	}
+	if c.pageConcurrency < 1 {
+		c.pageConcurrency = 1
+	}
+	if c.sortKey != "" && c.sortOrder == "" {
+		c.sortOrder = SortOrderDesc
+	}
+	c.httpClient = &http.Client{Timeout: c.timeout, Transport: c.transport}
+	return c
+}
+
+// GetVideoList retrieves the videos uploaded by a user.
+func (c *Client) GetVideoList(ctx context.Context, userID string, filter Filter) ([]Video, error) {
+	return c.collectVideoList(ctx, filter,
+		func(page int) string {
+			return fmt.Sprintf("%s/users/%s/videos?pageSize=%d&page=%d", c.baseURL, userID, pageSize, page) + c.sortQuery()
+		},
+		parseUserVideoPage,
+		c.newestFirst(true),
+	)
+}
+
+// GetMylistVideoList retrieves the videos registered in a mylist.
+func (c *Client) GetMylistVideoList(ctx context.Context, mylistID string, filter Filter) ([]Video, error) {
+	return c.collectVideoList(ctx, filter,
+		func(page int) string {
+			return fmt.Sprintf("%s/mylists/%s?pageSize=%d&page=%d", c.baseURL, mylistID, pageSize, page) + c.sortQuery()
+		},
+		parseMylistPage,
+		c.newestFirst(false),
+	)
+}
+
+func parseUserVideoPage(body []byte) (parsedPage, error) {
+	var nicoData NicoData
+	if err := json.Unmarshal(body, &nicoData); err != nil {
+		return parsedPage{}, err
+	}
+	var countPayload struct {
+		Data struct {
+			TotalCount *int `json:"totalCount"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &countPayload); err != nil {
+		return parsedPage{}, err
+	}
+	items := make([]Video, 0, len(nicoData.Data.Items))
+	for _, s := range nicoData.Data.Items {
+		items = append(items, videoFromEssential(s.Essential, s.Series))
+	}
+	totalCount := 0
+	if countPayload.Data.TotalCount != nil {
+		totalCount = *countPayload.Data.TotalCount
+	}
+	return parsedPage{
+		Items:           items,
+		Status:          nicoData.Meta.Status,
+		TotalCount:      totalCount,
+		TotalCountKnown: countPayload.Data.TotalCount != nil,
+	}, nil
+}
+
+func parseMylistPage(body []byte) (parsedPage, error) {
+	var payload struct {
+		Meta struct {
+			Status int `json:"status"`
+		} `json:"meta"`
+		Data struct {
+			TotalCount *int `json:"totalCount"`
+			Mylist     struct {
+				TotalCount     *int `json:"totalCount"`
+				TotalItemCount *int `json:"totalItemCount"`
+				Items          []struct {
+					Video NicoEssential `json:"video"`
+				} `json:"items"`
+			} `json:"mylist"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return parsedPage{}, err
+	}
+	items := make([]Video, 0, len(payload.Data.Mylist.Items))
+	for _, it := range payload.Data.Mylist.Items {
+		items = append(items, videoFromEssential(it.Video, NicoSeries{}))
+	}
+	totalCount := 0
+	totalCountKnown := false
+	if payload.Data.Mylist.TotalCount != nil {
+		totalCount = *payload.Data.Mylist.TotalCount
+		totalCountKnown = true
+	} else if payload.Data.Mylist.TotalItemCount != nil {
+		totalCount = *payload.Data.Mylist.TotalItemCount
+		totalCountKnown = true
+	} else if payload.Data.TotalCount != nil {
+		totalCount = *payload.Data.TotalCount
+		totalCountKnown = true
+	}
+	return parsedPage{
+		Items:           items,
+		Status:          payload.Meta.Status,
+		TotalCount:      totalCount,
+		TotalCountKnown: totalCountKnown,
+	}, nil
+}
+
+func (c *Client) collectVideoList(
+	ctx context.Context,
+	filter Filter,
+	requestURL func(page int) string,
+	parsePage parsePageFunc,
+	newestFirst bool,
+) ([]Video, error) {
+	firstPage, err := c.fetchPage(ctx, requestURL(1), parsePage)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if firstPage.NotFound || len(firstPage.Items) == 0 || pageBeforeRange(newestFirst, filter, firstPage.Items) {
+		return nil, nil
+	}
+	videos := filterItems(firstPage.Items, filter)
+	if shouldCollectSequentially(firstPage, c.pageConcurrency) {
+		return c.collectRemainingSequentially(ctx, videos, 2, filter, requestURL, parsePage, newestFirst)
+	}
+	totalPages := pageCountFor(firstPage.TotalCount)
+	if totalPages <= 1 {
+		return videos, nil
+	}
+	parallelVideos, err := c.collectPagesParallel(ctx, 2, totalPages, filter, requestURL, parsePage, newestFirst)
+	videos = append(videos, parallelVideos...)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, nil
+		}
+		return videos, err
+	}
+	return videos, nil
+}
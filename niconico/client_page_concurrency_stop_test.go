@@ -44,11 +44,11 @@ func TestGetVideoListPageConcurrencyIgnoresErrorAfterEmptyPage(t *testing.T) {
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 2, logger)
+	got, err := newTestClient(server.URL, 1, 2, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(got, []string{"sm1"}) {
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1"}) {
 		t.Fatalf("unexpected ids: %v", got)
 	}
 }
@@ -84,11 +84,11 @@ func TestGetVideoListPageConcurrencyReturnsEarlierPageBeforeLaterEmptyPage(t *te
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 2, logger)
+	got, err := newTestClient(server.URL, 1, 2, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(got, []string{"sm1", "sm2"}) {
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1", "sm2"}) {
 		t.Fatalf("unexpected ids: %v", got)
 	}
 }
@@ -125,11 +125,11 @@ func TestGetVideoListPageConcurrencyCancelsToEmptyResult(t *testing.T) {
 		err error
 	}, 1)
 	go func() {
-		ids, err := GetVideoList(ctx, "12345", 0, after, before, server.URL, 1, time.Second, nil, 2, logger)
+		ids, err := newTestClient(server.URL, 1, 2, logger).GetVideoList(ctx, "12345", Filter{CommentCount: 0, After: after, Before: before})
 		resultCh <- struct {
 			ids []string
 			err error
-		}{ids: ids, err: err}
+		}{ids: VideoIDs(ids), err: err}
 	}()
 	select {
 	case <-page2Started:
@@ -38,11 +38,11 @@ func TestGetVideoListSequentialNilItemsStopsPagination(t *testing.T) {
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 1, logger)
+	got, err := newTestClient(server.URL, 1, 1, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(got, []string{"sm1"}) {
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1"}) {
 		t.Fatalf("unexpected ids: %v", got)
 	}
 	mu.Lock()
@@ -73,11 +73,11 @@ func TestGetVideoListSequentialNotFoundStopsPagination(t *testing.T) {
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 2, logger)
+	got, err := newTestClient(server.URL, 1, 2, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(got, []string{"sm1"}) {
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1"}) {
 		t.Fatalf("unexpected ids: %v", got)
 	}
 	if !reflect.DeepEqual(requestedPages, []string{"1", "2"}) {
@@ -116,11 +116,11 @@ func TestGetVideoListPageConcurrencyPreservesPageOrder(t *testing.T) {
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 2, logger)
+	got, err := newTestClient(server.URL, 1, 2, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(got, []string{"sm1", "sm2", "sm3"}) {
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1", "sm2", "sm3"}) {
 		t.Fatalf("expected page-order ids, got %v", got)
 	}
 }
@@ -153,11 +153,11 @@ func TestGetVideoListPageConcurrencyStopsSchedulingAfterFetchError(t *testing.T)
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 2, logger)
+	got, err := newTestClient(server.URL, 1, 2, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err == nil {
 		t.Fatal("expected fetch error")
 	}
-	if !reflect.DeepEqual(got, []string{"sm1"}) {
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1"}) {
 		t.Fatalf("unexpected partial ids: %v", got)
 	}
 	mu.Lock()
@@ -200,11 +200,11 @@ func TestGetVideoListPageConcurrencyStopsAtEmptyPage(t *testing.T) {
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 2, logger)
+	got, err := newTestClient(server.URL, 1, 2, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(got, []string{"sm1"}) {
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1"}) {
 		t.Fatalf("unexpected ids: %v", got)
 	}
 }
@@ -234,11 +234,11 @@ func TestGetMylistVideoListPageConcurrencyUsesTotalItemCount(t *testing.T) {
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetMylistVideoList(context.Background(), "847130", 0, after, before, server.URL, 1, time.Second, nil, 2, logger)
+	got, err := newTestClient(server.URL, 1, 2, logger).GetMylistVideoList(context.Background(), "847130", Filter{CommentCount: 0, After: after, Before: before})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(got, []string{"sm1", "sm2"}) {
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1", "sm2"}) {
 		t.Fatalf("unexpected ids: %v", got)
 	}
 	mu.Lock()
@@ -8,6 +8,8 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"slices"
 	"strings"
@@ -20,6 +22,16 @@ type trackingReadCloser struct {
 	closed bool
 }
 
+func newTestClient(baseURL string, retries int, pageConcurrency int, logger *slog.Logger) *Client {
+	return NewClient(
+		WithBaseURL(baseURL),
+		WithRetries(retries),
+		WithTimeout(time.Second),
+		WithPageConcurrency(pageConcurrency),
+		WithLogger(logger),
+	)
+}
+
 func sameStringSet(got []string, want []string) bool {
 	gotCopy := append([]string{}, got...)
 	wantCopy := append([]string{}, want...)
@@ -86,7 +98,7 @@ func TestRetriesRequest(t *testing.T) {
 	}))
 	t.Cleanup(server.Close)
 
-	res, err := retriesRequest(context.Background(), server.URL, time.Second, retries, nil)
+	res, err := NewClient(WithRetries(retries), WithTimeout(time.Second)).retriesRequest(context.Background(), server.URL, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -113,7 +125,7 @@ func TestRetriesRequestExhaustedReturnsError(t *testing.T) {
 	}))
 	t.Cleanup(server.Close)
 
-	res, err := retriesRequest(context.Background(), server.URL, time.Second, retries, nil)
+	res, err := NewClient(WithRetries(retries), WithTimeout(time.Second)).retriesRequest(context.Background(), server.URL, nil)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -143,7 +155,7 @@ func TestRetriesRequestBackoffCanceled(t *testing.T) {
 
 	errCh := make(chan error, 1)
 	go func() {
-		res, err := retriesRequest(ctx, server.URL, time.Second, retries, nil)
+		res, err := NewClient(WithRetries(retries), WithTimeout(time.Second)).retriesRequest(ctx, server.URL, nil)
 		if res != nil {
 			_ = res.Body.Close()
 		}
@@ -174,7 +186,7 @@ func TestRetriesRequestBackoffCanceled(t *testing.T) {
 func TestRetriesRequestContextCanceled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
-	res, err := retriesRequest(ctx, "http://example.com", time.Second, 3, nil)
+	res, err := NewClient(WithRetries(3), WithTimeout(time.Second)).retriesRequest(ctx, "http://example.com", nil)
 	if !errors.Is(err, context.Canceled) {
 		t.Fatalf("expected context.Canceled, got %v", err)
 	}
@@ -195,7 +207,7 @@ func TestRetriesRequestTimeout(t *testing.T) {
 	t.Cleanup(server.Close)
 
 	timeout := 50 * time.Millisecond
-	res, err := retriesRequest(context.Background(), server.URL, timeout, 3, nil)
+	res, err := NewClient(WithRetries(3), WithTimeout(timeout)).retriesRequest(context.Background(), server.URL, nil)
 
 	if !errors.Is(err, context.DeadlineExceeded) {
 		t.Fatalf("expected context deadline exceeded, got %v", err)
@@ -469,12 +481,12 @@ func TestGetVideoList(t *testing.T) {
 		after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 		before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-		got, err := GetVideoList(context.Background(), "12345", 5, after, before, server.URL, 1, time.Second, nil, 1, logger)
+		got, err := newTestClient(server.URL, 1, 1, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 5, After: after, Before: before})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 		expected := []string{"sm1", "sm3"}
-		if !reflect.DeepEqual(got, expected) {
+		if !reflect.DeepEqual(VideoIDs(got), expected) {
 			t.Errorf("expected %v, got %v", expected, got)
 		}
 	})
@@ -495,11 +507,11 @@ func TestGetVideoList(t *testing.T) {
 		after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 		before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-		got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 1, logger)
+		got, err := newTestClient(server.URL, 1, 1, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if !reflect.DeepEqual(got, []string{"sm1"}) {
+		if !reflect.DeepEqual(VideoIDs(got), []string{"sm1"}) {
 			t.Errorf("expected [sm1], got %v", got)
 		}
 	})
@@ -516,7 +528,7 @@ func TestGetVideoList(t *testing.T) {
 		after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 		before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-		_, err := GetVideoList(context.Background(), "12345", 5, after, before, server.URL, 1, time.Second, nil, 1, logger)
+		_, err := newTestClient(server.URL, 1, 1, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 5, After: after, Before: before})
 		if err == nil {
 			t.Fatalf("expected error, got nil")
 		}
@@ -538,7 +550,7 @@ func TestGetVideoListContextCanceled(t *testing.T) {
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(ctx, "12345", 0, after, before, server.URL, 1, time.Second, nil, 1, logger)
+	got, err := newTestClient(server.URL, 1, 1, logger).GetVideoList(ctx, "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -561,7 +573,7 @@ func TestGetVideoListHandleNotFound(t *testing.T) {
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 1, logger)
+	got, err := newTestClient(server.URL, 1, 1, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -587,7 +599,7 @@ func TestGetVideoListHandleServerError(t *testing.T) {
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	_, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 2, time.Second, nil, 1, logger)
+	_, err := newTestClient(server.URL, 2, 1, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -615,11 +627,11 @@ func TestGetVideoListPartialOnError(t *testing.T) {
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 1, logger)
+	got, err := newTestClient(server.URL, 1, 1, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
-	if !reflect.DeepEqual(got, []string{"sm1"}) {
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1"}) {
 		t.Errorf("expected partial result, got %v", got)
 	}
 }
@@ -644,11 +656,11 @@ func TestGetVideoListPageConcurrencyReturnsPartialIDsOnFetchError(t *testing.T)
 	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
 
-	got, err := GetVideoList(context.Background(), "12345", 0, after, before, server.URL, 1, time.Second, nil, 2, logger)
+	got, err := newTestClient(server.URL, 1, 2, logger).GetVideoList(context.Background(), "12345", Filter{CommentCount: 0, After: after, Before: before})
 	if err == nil {
 		t.Fatalf("expected error")
 	}
-	if !sameStringSet(got, []string{"sm1", "sm2"}) {
+	if !sameStringSet(VideoIDs(got), []string{"sm1", "sm2"}) {
 		t.Fatalf("unexpected partial ids: %v", got)
 	}
 }
@@ -669,20 +681,238 @@ func TestGetMylistVideoList(t *testing.T) {
 	}))
 	t.Cleanup(server.Close)
 
-	ids, err := GetMylistVideoList(
+	ids, err := newTestClient(server.URL, 1, 1, slog.New(slog.DiscardHandler)).GetMylistVideoList(
 		context.Background(),
 		"847130",
-		0,
-		time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC),
-		time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC),
-		server.URL,
-		1,
-		time.Second, nil, 1, slog.New(slog.DiscardHandler),
+		Filter{
+			After:  time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC),
+			Before: time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
 	)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(ids, []string{"sm9"}) {
+	if !reflect.DeepEqual(VideoIDs(ids), []string{"sm9"}) {
 		t.Fatalf("unexpected ids: %v", ids)
 	}
 }
+
+type recordingTransport struct {
+	mu   sync.Mutex
+	urls []string
+	next http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.urls = append(t.urls, req.URL.String())
+	t.mu.Unlock()
+	return t.next.RoundTrip(req)
+}
+
+func TestNewClientDefaults(t *testing.T) {
+	client := NewClient()
+	if client.baseURL != DefaultBaseURL {
+		t.Errorf("unexpected base URL: %q", client.baseURL)
+	}
+	if client.retries != DefaultRetries {
+		t.Errorf("unexpected retries: %d", client.retries)
+	}
+	if client.httpClient.Timeout != DefaultTimeout {
+		t.Errorf("unexpected timeout: %v", client.httpClient.Timeout)
+	}
+	if client.pageConcurrency != 1 {
+		t.Errorf("unexpected page concurrency: %d", client.pageConcurrency)
+	}
+	if client.logger == nil {
+		t.Error("expected default logger")
+	}
+}
+
+func TestClientWithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"items":[{"essential":{"id":"sm1","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}}}]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	transport := &recordingTransport{next: http.DefaultTransport}
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithRetries(1),
+		WithTransport(transport),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	got, err := client.GetVideoList(context.Background(), "12345", Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1"}) {
+		t.Fatalf("unexpected ids: %v", got)
+	}
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	want := []string{
+		server.URL + "/users/12345/videos?pageSize=100&page=1",
+		server.URL + "/users/12345/videos?pageSize=100&page=2",
+	}
+	if !reflect.DeepEqual(transport.urls, want) {
+		t.Fatalf("unexpected requested URLs: %v", transport.urls)
+	}
+}
+
+func TestFilterZeroBoundsAreOpen(t *testing.T) {
+	items := []Video{
+		{ID: "sm1", Count: VideoCount{Comment: 1}, RegisteredAt: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "sm2", Count: VideoCount{Comment: 1}, RegisteredAt: time.Date(2990, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "sm3", Count: VideoCount{Comment: 0}, RegisteredAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	got := filterItems(items, Filter{})
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm1", "sm2"}) {
+		t.Fatalf("unexpected ids: %v", got)
+	}
+}
+
+func TestFilterBeforeUsesLocationAndUntilIsInclusive(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	items := []Video{
+		{ID: "sm1", Count: VideoCount{Comment: 1}, RegisteredAt: time.Date(2024, 5, 1, 14, 59, 59, 0, time.UTC)},
+		{ID: "sm2", Count: VideoCount{Comment: 1}, RegisteredAt: time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC)},
+	}
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if got := VideoIDs(filterItems(items, Filter{Before: day, Location: tokyo})); !reflect.DeepEqual(got, []string{"sm1"}) {
+		t.Fatalf("unexpected ids with location: %v", got)
+	}
+	if got := VideoIDs(filterItems(items, Filter{Before: day})); !reflect.DeepEqual(got, []string{"sm1", "sm2"}) {
+		t.Fatalf("unexpected ids without location: %v", got)
+	}
+	if got := VideoIDs(filterItems(items, Filter{Until: items[0].RegisteredAt})); !reflect.DeepEqual(got, []string{"sm1"}) {
+		t.Fatalf("unexpected ids with until: %v", got)
+	}
+}
+
+func TestFilterThresholdAndDurationBounds(t *testing.T) {
+	items := []Video{
+		{ID: "sm1", Count: VideoCount{View: 50, Like: 5, Mylist: 1, Comment: 3}, Duration: 60},
+		{ID: "sm2", Count: VideoCount{View: 150, Like: 5, Mylist: 1, Comment: 3}, Duration: 60},
+		{ID: "sm3", Count: VideoCount{View: 150, Like: 50, Mylist: 1, Comment: 3}, Duration: 60},
+		{ID: "sm4", Count: VideoCount{View: 150, Like: 5, Mylist: 10, Comment: 3}, Duration: 60},
+		{ID: "sm5", Count: VideoCount{View: 150, Like: 5, Mylist: 1, Comment: 30}, Duration: 60},
+		{ID: "sm6", Count: VideoCount{View: 150, Like: 5, Mylist: 1, Comment: 3}, Duration: 601},
+		{ID: "sm7", Count: VideoCount{View: 150, Like: 5, Mylist: 1, Comment: 3}, Duration: 10},
+		{ID: "sm8", Count: VideoCount{View: 100, Like: 10, Mylist: 5, Comment: 20}, Duration: 600},
+	}
+	filter := Filter{
+		MinViews:    100,
+		MaxLikes:    10,
+		MaxMylists:  5,
+		MaxComments: 20,
+		MinDuration: 30 * time.Second,
+		MaxDuration: 10 * time.Minute,
+	}
+	got := filterItems(items, filter)
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm2", "sm8"}) {
+		t.Fatalf("unexpected ids: %v", VideoIDs(got))
+	}
+}
+
+func TestFilterExclusionsAndOwnerType(t *testing.T) {
+	items := []Video{
+		{ID: "sm1", Count: VideoCount{Comment: 1}, Owner: VideoOwner{Type: OwnerTypeUser}},
+		{ID: "so2", Count: VideoCount{Comment: 1}, Owner: VideoOwner{Type: OwnerTypeChannel}, IsChannelVideo: true},
+		{ID: "sm3", Count: VideoCount{Comment: 1}, Owner: VideoOwner{Type: OwnerTypeUser}, IsPaymentRequired: true},
+		{ID: "sm4", Count: VideoCount{Comment: 1}, Owner: VideoOwner{Type: OwnerTypeUser}, RequireSensitiveMasking: true},
+		{ID: "so5", Count: VideoCount{Comment: 1}, Owner: VideoOwner{Type: OwnerTypeChannel}},
+	}
+	tests := []struct {
+		filter Filter
+		want   []string
+	}{
+		{filter: Filter{ExcludeChannel: true}, want: []string{"sm1", "sm3", "sm4", "so5"}},
+		{filter: Filter{ExcludePaid: true, ExcludeSensitive: true}, want: []string{"sm1", "so2", "so5"}},
+		{filter: Filter{OwnerType: OwnerTypeChannel}, want: []string{"so2", "so5"}},
+		{filter: Filter{OwnerType: OwnerTypeUser, ExcludePaid: true}, want: []string{"sm1", "sm4"}},
+	}
+	for _, tt := range tests {
+		if got := VideoIDs(filterItems(items, tt.filter)); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("filter %+v: unexpected ids %v", tt.filter, got)
+		}
+	}
+}
+
+func TestGetVideoListReturnsMetadata(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "nvapi_user_videos_page1.json"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+			return
+		}
+		_, _ = w.Write(fixture)
+	}))
+	t.Cleanup(server.Close)
+
+	got, err := newTestClient(server.URL, 1, 1, slog.New(slog.DiscardHandler)).GetVideoList(context.Background(), "1", Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("unexpected videos length: %d", len(got))
+	}
+	want := Video{
+		ID:               "sm9",
+		Title:            "sample title 1",
+		RegisteredAt:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Count:            VideoCount{View: 100, Comment: 12, Mylist: 3, Like: 8},
+		Duration:         120,
+		ShortDescription: "desc",
+		Thumbnail: VideoThumbnail{
+			URL:        "https://example.invalid/thumb1.jpg",
+			MiddleURL:  "https://example.invalid/thumb1-m.jpg",
+			LargeURL:   "https://example.invalid/thumb1-l.jpg",
+			ListingURL: "https://example.invalid/thumb1-s.jpg",
+			NHdURL:     "https://example.invalid/thumb1-hd.jpg",
+		},
+		Owner: VideoOwner{Type: "user", ID: "1", Name: "owner-1", IconURL: "https://example.invalid/icon1.png"},
+	}
+	if !reflect.DeepEqual(got[0], want) {
+		t.Fatalf("unexpected video:\n got %+v\nwant %+v", got[0], want)
+	}
+}
+
+func TestGetMylistVideoListReturnsMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"mylist":{"items":[]}}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"mylist":{"items":[{"video":{"id":"so7","title":"paid","registeredAt":"2025-01-02T03:04:05Z","count":{"view":5,"comment":1},"duration":60,"isChannelVideo":true,"isPaymentRequired":true,"owner":{"ownerType":"channel","id":"ch1","name":"channel-1"}}}]}}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	got, err := newTestClient(server.URL, 1, 1, slog.New(slog.DiscardHandler)).GetMylistVideoList(context.Background(), "1", Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("unexpected videos length: %d", len(got))
+	}
+	video := got[0]
+	if video.Title != "paid" || video.Count.View != 5 || video.Duration != 60 {
+		t.Errorf("unexpected video metadata: %+v", video)
+	}
+	if !video.IsChannelVideo || !video.IsPaymentRequired || video.Owner.Type != "channel" || video.Owner.ID != "ch1" {
+		t.Errorf("unexpected video flags or owner: %+v", video)
+	}
+	if video.Series != nil {
+		t.Errorf("expected no series, got %+v", video.Series)
+	}
+}
@@ -3,43 +3,36 @@ package niconico
 import (
 	"context"
 	"errors"
-	"log/slog"
 	"sync"
 	"sync/atomic"
-	"time"
 )
 
-func collectRemainingSequentially(
+func (c *Client) collectRemainingSequentially(
 	ctx context.Context,
-	resStr []string,
+	videos []Video,
 	startPage int,
-	commentCount int,
-	afterDate time.Time,
-	beforeDate time.Time,
-	retries int,
-	httpClientTimeout time.Duration,
-	limiter *RateLimiter,
-	logger *slog.Logger,
+	filter Filter,
 	requestURL func(page int) string,
 	parsePage parsePageFunc,
-) ([]string, error) {
+	newestFirst bool,
+) ([]Video, error) {
 	for page := startPage; ; page++ {
-		parsed, err := fetchPage(ctx, requestURL(page), httpClientTimeout, retries, limiter, logger, parsePage)
+		parsed, err := c.fetchPage(ctx, requestURL(page), parsePage)
 		if err != nil {
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				return nil, nil
 			}
-			return resStr, err
+			return videos, err
 		}
 		if parsed.NotFound {
 			break
 		}
-		if len(parsed.Items) == 0 {
+		if len(parsed.Items) == 0 || pageBeforeRange(newestFirst, filter, parsed.Items) {
 			break
 		}
-		resStr = append(resStr, filterItems(parsed.Items, commentCount, afterDate, beforeDate)...)
+		videos = append(videos, filterItems(parsed.Items, filter)...)
 	}
-	return resStr, nil
+	return videos, nil
 }
 
 func shouldCollectSequentially(firstPage parsedPage, pageConcurrency int) bool {
@@ -55,7 +48,7 @@ func pageCountFor(totalCount int) int {
 
 type pageResult struct {
 	page      int
-	ids       []string
+	videos    []Video
 	err       error
 	terminate bool
 }
@@ -73,29 +66,23 @@ func lowerStopBefore(stopBefore *atomic.Int64, page int) {
 	}
 }
 
-func collectPagesParallel(
+func (c *Client) collectPagesParallel(
 	ctx context.Context,
 	startPage int,
 	endPage int,
-	pageConcurrency int,
-	commentCount int,
-	afterDate time.Time,
-	beforeDate time.Time,
-	retries int,
-	httpClientTimeout time.Duration,
-	limiter *RateLimiter,
-	logger *slog.Logger,
+	filter Filter,
 	requestURL func(page int) string,
 	parsePage parsePageFunc,
-) ([]string, error) {
+	newestFirst bool,
+) ([]Video, error) {
 	pages := make(chan int)
-	results := make(chan pageResult, pageConcurrency)
+	results := make(chan pageResult, c.pageConcurrency)
 	stopScheduling := make(chan struct{})
 	var stopOnce sync.Once
 	var stopBefore atomic.Int64
 	stopBefore.Store(int64(endPage + 1))
 	var wg sync.WaitGroup
-	for range pageConcurrency {
+	for range c.pageConcurrency {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -103,21 +90,21 @@ func collectPagesParallel(
 				if int64(page) >= stopBefore.Load() {
 					return
 				}
-				parsed, err := fetchPage(ctx, requestURL(page), httpClientTimeout, retries, limiter, logger, parsePage)
+				parsed, err := c.fetchPage(ctx, requestURL(page), parsePage)
 				if err != nil {
 					lowerStopBefore(&stopBefore, page)
 					stopOnce.Do(func() { close(stopScheduling) })
 					results <- pageResult{page: page, err: err}
 					return
 				}
-				if parsed.NotFound || len(parsed.Items) == 0 {
+				if parsed.NotFound || len(parsed.Items) == 0 || pageBeforeRange(newestFirst, filter, parsed.Items) {
 					lowerStopBefore(&stopBefore, page)
 					stopOnce.Do(func() { close(stopScheduling) })
 					results <- pageResult{page: page, terminate: true}
 					return
 				}
 				select {
-				case results <- pageResult{page: page, ids: filterItems(parsed.Items, commentCount, afterDate, beforeDate)}:
+				case results <- pageResult{page: page, videos: filterItems(parsed.Items, filter)}:
 				case <-ctx.Done():
 					return
 				}
@@ -153,7 +140,7 @@ func collectPagesParallel(
 		close(results)
 	}()
 
-	idsByPage := make(map[int][]string)
+	videosByPage := make(map[int][]Video)
 	var firstErr error
 	stopAtPage := endPage + 1
 	for result := range results {
@@ -171,14 +158,14 @@ func collectPagesParallel(
 			}
 			continue
 		}
-		idsByPage[result.page] = result.ids
+		videosByPage[result.page] = result.videos
 	}
 	if firstErr == nil && ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
-	var ids []string
+	var videos []Video
 	for page := startPage; page < stopAtPage; page++ {
-		ids = append(ids, idsByPage[page]...)
+		videos = append(videos, videosByPage[page]...)
 	}
-	return ids, firstErr
+	return videos, firstErr
 }
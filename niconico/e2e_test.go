@@ -30,27 +30,24 @@ func TestGetVideoListE2E(t *testing.T) {
 	defer cancel()
 
 	logger := slog.New(slog.DiscardHandler)
-	ids, err := GetVideoList(
-		ctx,
-		userID,
-		0,
-		time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC),
-		time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC),
-		baseURL,
-		3,
-		10*time.Second,
-		nil,
-		1,
-		logger,
+	client := NewClient(
+		WithBaseURL(baseURL),
+		WithRetries(3),
+		WithTimeout(10*time.Second),
+		WithLogger(logger),
 	)
+	videos, err := client.GetVideoList(ctx, userID, Filter{
+		After:  time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC),
+		Before: time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC),
+	})
 	if err != nil {
 		t.Fatalf("GetVideoList returned error: %v", err)
 	}
-	if len(ids) == 0 {
+	if len(videos) == 0 {
 		t.Fatalf("expected at least one id for user %s", userID)
 	}
 	videoIDPattern := regexp.MustCompile(`^(sm|so|nm)\d+$`)
-	for _, id := range ids {
+	for _, id := range VideoIDs(videos) {
 		if !videoIDPattern.MatchString(id) {
 			t.Fatalf("unexpected video id format: %q", id)
 		}
@@ -0,0 +1,470 @@
+package niconico
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expr is a compiled boolean filter expression evaluated against each video.
+//
+// Expressions combine comparisons with &&, ||, ! and parentheses, for example
+// `views > 1000 && (comments > 50 || likes > 100) && !channel && title =~ "MMD"`.
+// Fields are id, title, description, owner, owner_name, owner_type, and series (strings);
+// views, comments, mylists, likes, and duration in seconds (ints, where duration literals such as 10m are allowed);
+// registered (a time compared with "YYYY-MM-DD", "YYYYMMDD", or RFC3339 strings); and channel, paid, and sensitive (bools).
+type Expr struct {
+	source string
+	eval   func(Video) bool
+}
+
+// ExprError reports a parse or type error in a filter expression at a 1-based column.
+type ExprError struct {
+	Column  int
+	Message string
+}
+
+// Error implements error.
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Message)
+}
+
+// ParseExpr compiles a filter expression, returning an *ExprError for invalid input.
+// Date-only literals compared with registered are interpreted in UTC.
+func ParseExpr(source string) (*Expr, error) {
+	return ParseExprInLocation(source, time.UTC)
+}
+
+// ParseExprInLocation is like ParseExpr but interprets date-only literals in loc.
+func ParseExprInLocation(source string, loc *time.Location) (*Expr, error) {
+	tokens, err := lexExpr(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, loc: loc}
+	eval, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != exprTokenEOF {
+		return nil, &ExprError{Column: tok.column, Message: fmt.Sprintf("unexpected %s", tok)}
+	}
+	return &Expr{source: source, eval: eval}, nil
+}
+
+// Match reports whether video satisfies the expression.
+func (e *Expr) Match(video Video) bool {
+	return e.eval(video)
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.source
+}
+
+type exprTokenKind int
+
+const (
+	exprTokenEOF exprTokenKind = iota
+	exprTokenIdent
+	exprTokenInt
+	exprTokenString
+	exprTokenOp
+)
+
+type exprToken struct {
+	kind   exprTokenKind
+	text   string
+	value  int
+	column int
+}
+
+func (t exprToken) String() string {
+	switch t.kind {
+	case exprTokenEOF:
+		return "end of expression"
+	case exprTokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// exprOperators lists operator tokens, longest first so that "<=" wins over "<".
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")"}
+
+// lexExpr splits source into tokens with 1-based rune columns.
+func lexExpr(source string) ([]exprToken, error) {
+	runes := []rune(source)
+	var tokens []exprToken
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		column := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenIdent, text: string(runes[start:i]), column: column})
+		case r >= '0' && r <= '9':
+			start := i
+			for i < len(runes) && (runes[i] == '.' || runes[i] == 'µ' || (runes[i] < unicode.MaxASCII && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])))) {
+				i++
+			}
+			text := string(runes[start:i])
+			value, err := parseExprNumber(text)
+			if err != nil {
+				return nil, &ExprError{Column: column, Message: err.Error()}
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenInt, text: text, value: value, column: column})
+		case r == '"':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, &ExprError{Column: column, Message: "unterminated string"}
+			}
+			i++
+			text, err := strconv.Unquote(string(runes[start:i]))
+			if err != nil {
+				return nil, &ExprError{Column: column, Message: "invalid string literal"}
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenString, text: text, column: column})
+		default:
+			op := ""
+			rest := string(runes[i:])
+			for _, candidate := range exprOperators {
+				if strings.HasPrefix(rest, candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, &ExprError{Column: column, Message: fmt.Sprintf("unexpected character %q", r)}
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenOp, text: op, column: column})
+			i += len(op)
+		}
+	}
+	return append(tokens, exprToken{kind: exprTokenEOF, column: len(runes) + 1}), nil
+}
+
+// parseExprNumber parses an integer literal or a duration literal such as 10m, returned in seconds.
+func parseExprNumber(text string) (int, error) {
+	if value, err := strconv.Atoi(text); err == nil {
+		return value, nil
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", text)
+	}
+	return int(duration / time.Second), nil
+}
+
+type exprType int
+
+const (
+	exprTypeInt exprType = iota
+	exprTypeString
+	exprTypeBool
+	exprTypeTime
+)
+
+func (t exprType) String() string {
+	switch t {
+	case exprTypeInt:
+		return "int"
+	case exprTypeString:
+		return "string"
+	case exprTypeBool:
+		return "bool"
+	default:
+		return "time"
+	}
+}
+
+// exprOperand is a field or literal on one side of a comparison.
+type exprOperand struct {
+	typ     exprType
+	column  int
+	literal *exprToken
+	value   func(Video) any
+}
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	loc    *time.Location
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != exprTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) acceptOp(op string) bool {
+	if tok := p.peek(); tok.kind == exprTokenOp && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (func(Video) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOp("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(v Video) bool { return l(v) || right(v) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (func(Video) bool, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOp("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(v Video) bool { return l(v) && right(v) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (func(Video) bool, error) {
+	if p.acceptOp("!") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v Video) bool { return !inner(v) }, nil
+	}
+	if open := p.peek(); p.acceptOp("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptOp(")") {
+			tok := p.peek()
+			return nil, &ExprError{Column: tok.column, Message: fmt.Sprintf("expected \")\" to close \"(\" at column %d, got %s", open.column, tok)}
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (func(Video) bool, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	if op.kind != exprTokenOp || !isExprComparison(op.text) {
+		if left.typ != exprTypeBool {
+			return nil, &ExprError{Column: op.column, Message: fmt.Sprintf("expected comparison operator after %s operand, got %s", left.typ, op)}
+		}
+		return func(v Video) bool { return left.value(v).(bool) }, nil
+	}
+	p.pos++
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return compileExprComparison(op, left, right, p.loc)
+}
+
+func (p *exprParser) parseOperand() (exprOperand, error) {
+	tok := p.next()
+	switch tok.kind {
+	case exprTokenInt:
+		value := tok.value
+		return exprOperand{typ: exprTypeInt, column: tok.column, literal: &tok, value: func(Video) any { return value }}, nil
+	case exprTokenString:
+		value := tok.text
+		return exprOperand{typ: exprTypeString, column: tok.column, literal: &tok, value: func(Video) any { return value }}, nil
+	case exprTokenIdent:
+		switch tok.text {
+		case "true", "false":
+			value := tok.text == "true"
+			return exprOperand{typ: exprTypeBool, column: tok.column, literal: &tok, value: func(Video) any { return value }}, nil
+		}
+		operand, ok := exprField(tok.text)
+		if !ok {
+			return exprOperand{}, &ExprError{Column: tok.column, Message: fmt.Sprintf("unknown field %q", tok.text)}
+		}
+		operand.column = tok.column
+		return operand, nil
+	default:
+		return exprOperand{}, &ExprError{Column: tok.column, Message: fmt.Sprintf("expected field or value, got %s", tok)}
+	}
+}
+
+// exprField returns the operand reading the named field from a video.
+func exprField(name string) (exprOperand, bool) {
+	var typ exprType
+	var value func(Video) any
+	switch name {
+	case "id":
+		typ, value = exprTypeString, func(v Video) any { return v.ID }
+	case "title":
+		typ, value = exprTypeString, func(v Video) any { return v.Title }
+	case "description":
+		typ, value = exprTypeString, func(v Video) any { return v.ShortDescription }
+	case "owner":
+		typ, value = exprTypeString, func(v Video) any { return v.Owner.ID }
+	case "owner_name":
+		typ, value = exprTypeString, func(v Video) any { return v.Owner.Name }
+	case "owner_type":
+		typ, value = exprTypeString, func(v Video) any { return v.Owner.Type }
+	case "series":
+		typ, value = exprTypeString, func(v Video) any {
+			if v.Series == nil {
+				return ""
+			}
+			return v.Series.Title
+		}
+	case "views":
+		typ, value = exprTypeInt, func(v Video) any { return v.Count.View }
+	case "comments":
+		typ, value = exprTypeInt, func(v Video) any { return v.Count.Comment }
+	case "mylists":
+		typ, value = exprTypeInt, func(v Video) any { return v.Count.Mylist }
+	case "likes":
+		typ, value = exprTypeInt, func(v Video) any { return v.Count.Like }
+	case "duration":
+		typ, value = exprTypeInt, func(v Video) any { return v.Duration }
+	case "registered":
+		typ, value = exprTypeTime, func(v Video) any { return v.RegisteredAt }
+	case "channel":
+		typ, value = exprTypeBool, func(v Video) any { return v.IsChannelVideo }
+	case "paid":
+		typ, value = exprTypeBool, func(v Video) any { return v.IsPaymentRequired }
+	case "sensitive":
+		typ, value = exprTypeBool, func(v Video) any { return v.RequireSensitiveMasking }
+	default:
+		return exprOperand{}, false
+	}
+	return exprOperand{typ: typ, value: value}, true
+}
+
+func isExprComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "=~", "!~":
+		return true
+	}
+	return false
+}
+
+// exprTimeLayouts lists the accepted layouts for string literals compared with registered.
+var exprTimeLayouts = []string{time.RFC3339, "2006-01-02", "20060102"}
+
+// coerceExprTime turns a string literal compared with a time field into a time literal.
+func coerceExprTime(operand exprOperand, loc *time.Location) (exprOperand, error) {
+	if operand.typ != exprTypeString || operand.literal == nil {
+		return operand, nil
+	}
+	for _, layout := range exprTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, operand.literal.text, loc); err == nil {
+			return exprOperand{typ: exprTypeTime, column: operand.column, literal: operand.literal, value: func(Video) any { return parsed }}, nil
+		}
+	}
+	return operand, &ExprError{Column: operand.column, Message: fmt.Sprintf("invalid time %q (want YYYY-MM-DD, YYYYMMDD, or RFC3339)", operand.literal.text)}
+}
+
+func compileExprComparison(op exprToken, left, right exprOperand, loc *time.Location) (func(Video) bool, error) {
+	if op.text == "=~" || op.text == "!~" {
+		if left.typ != exprTypeString {
+			return nil, &ExprError{Column: left.column, Message: fmt.Sprintf("%s needs a string operand, got %s", op.text, left.typ)}
+		}
+		if right.typ != exprTypeString || right.literal == nil {
+			return nil, &ExprError{Column: right.column, Message: fmt.Sprintf("%s needs a string literal pattern", op.text)}
+		}
+		pattern, err := regexp.Compile(right.literal.text)
+		if err != nil {
+			return nil, &ExprError{Column: right.column, Message: fmt.Sprintf("invalid pattern: %v", err)}
+		}
+		want := op.text == "=~"
+		return func(v Video) bool { return pattern.MatchString(left.value(v).(string)) == want }, nil
+	}
+	var err error
+	if left.typ == exprTypeTime {
+		right, err = coerceExprTime(right, loc)
+	} else if right.typ == exprTypeTime {
+		left, err = coerceExprTime(left, loc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if left.typ != right.typ {
+		return nil, &ExprError{Column: op.column, Message: fmt.Sprintf("cannot compare %s with %s", left.typ, right.typ)}
+	}
+	ordered := op.text != "==" && op.text != "!="
+	if ordered && (left.typ == exprTypeString || left.typ == exprTypeBool) {
+		return nil, &ExprError{Column: op.column, Message: fmt.Sprintf("operator %s is not defined on %s", op.text, left.typ)}
+	}
+	return func(v Video) bool {
+		return exprCompareResult(op.text, exprCompare(left.value(v), right.value(v)))
+	}, nil
+}
+
+// exprCompare returns -1, 0, or 1 for two values of the same type; bools and strings are only tested for equality.
+func exprCompare(a, b any) int {
+	switch a := a.(type) {
+	case int:
+		return cmp.Compare(a, b.(int))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	default:
+		if a == b {
+			return 0
+		}
+		return 1
+	}
+}
+
+func exprCompareResult(op string, c int) bool {
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
@@ -0,0 +1,113 @@
+package niconico
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExprMatch(t *testing.T) {
+	video := Video{
+		ID:                "sm9",
+		Title:             "MMD dance",
+		RegisteredAt:      time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Count:             VideoCount{View: 5000, Comment: 30, Mylist: 4, Like: 150},
+		Duration:          245,
+		ShortDescription:  "description",
+		Owner:             VideoOwner{Type: "user", ID: "12345", Name: "owner"},
+		IsPaymentRequired: true,
+	}
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{source: `views > 1000 && (comments > 50 || likes > 100) && !channel && title =~ "MMD"`, want: true},
+		{source: `views > 1000 && comments > 50`, want: false},
+		{source: `!(views > 1000)`, want: false},
+		{source: `title !~ "(?i)mmd"`, want: false},
+		{source: `duration <= 5m && duration > 4m`, want: true},
+		{source: `registered >= "2024-03-01" && registered < "20240302"`, want: true},
+		{source: `registered > "2024-03-01T12:00:00Z"`, want: false},
+		{source: `owner == "12345" && owner_type != "channel" && paid == true`, want: true},
+		{source: `likes > views || sensitive`, want: false},
+		{source: `series == ""`, want: true},
+		{source: `false || id == "sm9"`, want: true},
+	}
+	for _, tt := range tests {
+		expr, err := ParseExpr(tt.source)
+		if err != nil {
+			t.Fatalf("ParseExpr(%q): %v", tt.source, err)
+		}
+		if got := expr.Match(video); got != tt.want {
+			t.Fatalf("Match(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+		if expr.String() != tt.source {
+			t.Fatalf("unexpected String(): %q", expr.String())
+		}
+	}
+}
+
+func TestParseExprReportsColumn(t *testing.T) {
+	tests := []struct {
+		source string
+		column int
+		want   string
+	}{
+		{source: `views > 1000 && plays > 5`, column: 17, want: `column 17: unknown field "plays"`},
+		{source: `views >`, column: 8, want: `column 8: expected field or value, got end of expression`},
+		{source: `views > 10 &&& likes`, column: 14, want: `column 14: unexpected character '&'`},
+		{source: `title == 5`, column: 7, want: `column 7: cannot compare string with int`},
+		{source: `title > "a"`, column: 7, want: `column 7: operator > is not defined on string`},
+		{source: `(views > 1`, column: 11, want: `column 11: expected ")" to close "(" at column 1, got end of expression`},
+		{source: `views`, column: 6, want: `column 6: expected comparison operator after int operand, got end of expression`},
+		{source: `title =~ "("`, column: 10, want: "column 10: invalid pattern: error parsing regexp: missing closing ): `(`"},
+		{source: `registered > "yesterday"`, column: 14, want: `column 14: invalid time "yesterday" (want YYYY-MM-DD, YYYYMMDD, or RFC3339)`},
+		{source: `title == "abc`, column: 10, want: `column 10: unterminated string`},
+		{source: `duration > 10q`, column: 12, want: `column 12: invalid number "10q"`},
+		{source: `views > 1 likes`, column: 11, want: `column 11: unexpected "likes"`},
+		{source: `"タイトル" == titel`, column: 11, want: `column 11: unknown field "titel"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseExpr(tt.source)
+		var exprErr *ExprError
+		if !errors.As(err, &exprErr) {
+			t.Fatalf("ParseExpr(%q): expected *ExprError, got %v", tt.source, err)
+		}
+		if exprErr.Column != tt.column || err.Error() != tt.want {
+			t.Fatalf("ParseExpr(%q): got %q (column %d), want %q", tt.source, err.Error(), exprErr.Column, tt.want)
+		}
+	}
+}
+
+func TestFilterAppliesExpr(t *testing.T) {
+	expr, err := ParseExpr(`likes >= 10 || title =~ "keep"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items := []Video{
+		{ID: "sm1", Count: VideoCount{Comment: 1, Like: 10}},
+		{ID: "sm2", Count: VideoCount{Comment: 1, Like: 1}},
+		{ID: "sm3", Title: "keep me", Count: VideoCount{Comment: 1}},
+		{ID: "sm4", Title: "keep me", Count: VideoCount{Comment: 0, Like: 10}},
+	}
+	got := VideoIDs(filterItems(items, Filter{Expr: expr}))
+	if len(got) != 2 || got[0] != "sm1" || got[1] != "sm3" {
+		t.Fatalf("unexpected ids: %v", got)
+	}
+}
+
+func TestParseExprInLocationDateLiterals(t *testing.T) {
+	video := Video{RegisteredAt: time.Date(2024, 4, 30, 16, 0, 0, 0, time.UTC)}
+	source := `registered >= "2024-05-01"`
+	utc, err := ParseExpr(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jst, err := ParseExprInLocation(source, time.FixedZone("JST", 9*60*60))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if utc.Match(video) || !jst.Match(video) {
+		t.Fatalf("expected only the JST expression to match, utc=%v jst=%v", utc.Match(video), jst.Match(video))
+	}
+}
@@ -0,0 +1,116 @@
+package niconico
+
+import "time"
+
+// Filter selects which videos a Client returns; zero values leave a check open, except CommentCount.
+type Filter struct {
+	// CommentCount is an exclusive lower bound on the comment count, so zero drops videos without comments and -1 keeps them.
+	CommentCount int
+	// After is the inclusive lower bound on the registration time.
+	After time.Time
+	// Before is the inclusive upper bound on the registration date.
+	Before time.Time
+	// Until is the inclusive upper bound on the registration time.
+	Until time.Time
+	// Location, when set, is the time zone whose day boundaries Before is evaluated in;
+	// otherwise Before plus one day is the exclusive upper bound.
+	Location *time.Location
+
+	// MinViews and MaxViews are inclusive bounds on the view count.
+	MinViews, MaxViews int
+	// MinLikes and MaxLikes are inclusive bounds on the like count.
+	MinLikes, MaxLikes int
+	// MinMylists and MaxMylists are inclusive bounds on the mylist count.
+	MinMylists, MaxMylists int
+	// MinComments and MaxComments are inclusive bounds on the comment count.
+	MinComments, MaxComments int
+	// MinDuration and MaxDuration are inclusive bounds on the video length.
+	MinDuration, MaxDuration time.Duration
+
+	// ExcludeChannel, ExcludePaid, and ExcludeSensitive drop channel, paid, and sensitive-masked videos.
+	ExcludeChannel, ExcludePaid, ExcludeSensitive bool
+	// OwnerType, when set, keeps only videos whose owner type equals it ("user" or "channel").
+	OwnerType string
+
+	// TitleMatch and DescriptionMatch, when set, must match the title and short description.
+	TitleMatch, DescriptionMatch *TextPattern
+	// TitleExclude and DescriptionExclude, when set, drop videos whose title or short description matches.
+	TitleExclude, DescriptionExclude *TextPattern
+
+	// Expr, when set, is a filter expression every returned video must also satisfy.
+	Expr *Expr
+}
+
+// Match reports whether item passes the filter.
+func (f Filter) Match(item Video) bool {
+	if item.Count.Comment <= f.CommentCount {
+		return false
+	}
+	if item.RegisteredAt.Before(f.After) {
+		return false
+	}
+	if end := f.beforeEnd(); !end.IsZero() && !item.RegisteredAt.Before(end) {
+		return false
+	}
+	if !f.Until.IsZero() && item.RegisteredAt.After(f.Until) {
+		return false
+	}
+	if !inRange(item.Count.View, f.MinViews, f.MaxViews) ||
+		!inRange(item.Count.Like, f.MinLikes, f.MaxLikes) ||
+		!inRange(item.Count.Mylist, f.MinMylists, f.MaxMylists) ||
+		!inRange(item.Count.Comment, f.MinComments, f.MaxComments) {
+		return false
+	}
+	duration := time.Duration(item.Duration) * time.Second
+	if (f.MinDuration > 0 && duration < f.MinDuration) || (f.MaxDuration > 0 && duration > f.MaxDuration) {
+		return false
+	}
+	if (f.ExcludeChannel && item.IsChannelVideo) ||
+		(f.ExcludePaid && item.IsPaymentRequired) ||
+		(f.ExcludeSensitive && item.RequireSensitiveMasking) ||
+		(f.OwnerType != "" && item.Owner.Type != f.OwnerType) {
+		return false
+	}
+	if (f.TitleMatch != nil && !f.TitleMatch.MatchString(item.Title)) ||
+		(f.TitleExclude != nil && f.TitleExclude.MatchString(item.Title)) ||
+		(f.DescriptionMatch != nil && !f.DescriptionMatch.MatchString(item.ShortDescription)) ||
+		(f.DescriptionExclude != nil && f.DescriptionExclude.MatchString(item.ShortDescription)) {
+		return false
+	}
+	return f.Expr == nil || f.Expr.Match(item)
+}
+
+// beforeEnd returns the exclusive end of the Before day, or the zero time when Before is open.
+func (f Filter) beforeEnd() time.Time {
+	if f.Before.IsZero() {
+		return time.Time{}
+	}
+	if f.Location == nil {
+		return f.Before.AddDate(0, 0, 1)
+	}
+	year, month, day := f.Before.In(f.Location).Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, f.Location)
+}
+
+// inRange reports whether value lies within [minimum, maximum], treating zero bounds as open.
+func inRange(value, minimum, maximum int) bool {
+	if minimum > 0 && value < minimum {
+		return false
+	}
+	if maximum > 0 && value > maximum {
+		return false
+	}
+	return true
+}
+
+// filterItems returns the items that pass the filter.
+func filterItems(items []Video, filter Filter) []Video {
+	videos := make([]Video, 0, len(items))
+	for _, item := range items {
+		if !filter.Match(item) {
+			continue
+		}
+		videos = append(videos, item)
+	}
+	return videos
+}
@@ -29,3 +29,17 @@ func FuzzNicoDataUnmarshalNoPanic(f *testing.F) {
 		_ = json.Unmarshal(b, &payload)
 	})
 }
+
+func FuzzParseExprNoPanic(f *testing.F) {
+	f.Add(`views > 1000 && (comments > 50 || likes > 100) && !channel && title =~ "MMD"`)
+	f.Add(`registered >= "2024-01-01" && duration <= 10m`)
+	f.Add(`(("`)
+
+	f.Fuzz(func(t *testing.T, source string) {
+		expr, err := ParseExpr(source)
+		if err != nil {
+			return
+		}
+		_ = expr.Match(Video{})
+	})
+}
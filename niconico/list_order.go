@@ -0,0 +1,74 @@
+package niconico
+
+// SortKey selects the server-side order of user, mylist, and channel video pages.
+type SortKey string
+
+const (
+	SortKeyRegisteredAt    SortKey = "registeredAt"
+	SortKeyAddedAt         SortKey = "addedAt"
+	SortKeyViewCount       SortKey = "viewCount"
+	SortKeyCommentCount    SortKey = "commentCount"
+	SortKeyMylistCount     SortKey = "mylistCount"
+	SortKeyLikeCount       SortKey = "likeCount"
+	SortKeyLastCommentTime SortKey = "lastCommentTime"
+	SortKeyDuration        SortKey = "duration"
+)
+
+// SortKeys lists the supported sort keys.
+var SortKeys = []SortKey{
+	SortKeyRegisteredAt,
+	SortKeyAddedAt,
+	SortKeyViewCount,
+	SortKeyCommentCount,
+	SortKeyMylistCount,
+	SortKeyLikeCount,
+	SortKeyLastCommentTime,
+	SortKeyDuration,
+}
+
+// SortOrder selects ascending or descending server-side order.
+type SortOrder string
+
+const (
+	SortOrderAsc  SortOrder = "asc"
+	SortOrderDesc SortOrder = "desc"
+)
+
+// WithSort requests video pages in server-side order; an empty key keeps the API default and an empty order means descending.
+func WithSort(key SortKey, order SortOrder) Option {
+	return func(c *Client) {
+		c.sortKey = key
+		c.sortOrder = order
+	}
+}
+
+// sortQuery returns the sort query parameters to append to a page URL, or "" for the API default order.
+func (c *Client) sortQuery() string {
+	if c.sortKey == "" {
+		return ""
+	}
+	return "&sortKey=" + string(c.sortKey) + "&sortOrder=" + string(c.sortOrder)
+}
+
+// newestFirst reports whether pages built with sortQuery are ordered by registration date descending;
+// without a sort key the list's API default decides, which is newest first for user and channel videos.
+func (c *Client) newestFirst(defaultNewestFirst bool) bool {
+	if c.sortKey == "" {
+		return defaultNewestFirst
+	}
+	return c.sortKey == SortKeyRegisteredAt && c.sortOrder == SortOrderDesc
+}
+
+// pageBeforeRange reports whether every item on a newest-first page was registered before filter.After,
+// which means no later page can match either.
+func pageBeforeRange(newestFirst bool, filter Filter, items []Video) bool {
+	if !newestFirst || filter.After.IsZero() || len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if !item.RegisteredAt.Before(filter.After) {
+			return false
+		}
+	}
+	return true
+}
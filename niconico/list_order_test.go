@@ -0,0 +1,164 @@
+package niconico
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newDatedPageServer serves user video pages whose items are registered on the dates in pages (1-based page order).
+func newDatedPageServer(t *testing.T, totalCount int, pages [][]string) (*httptest.Server, func() []int) {
+	t.Helper()
+	var mu sync.Mutex
+	var requested []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		mu.Lock()
+		requested = append(requested, page)
+		mu.Unlock()
+		if got := r.URL.Query().Get("sortKey") + "/" + r.URL.Query().Get("sortOrder"); got != "registeredAt/desc" && got != "registeredAt/asc" && got != "/" {
+			t.Errorf("unexpected sort parameters: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		payload := `{"meta":{"status":200},"data":{`
+		if totalCount > 0 {
+			payload += fmt.Sprintf(`"totalCount":%d,`, totalCount)
+		}
+		payload += `"items":[`
+		if page >= 1 && page <= len(pages) {
+			for i, date := range pages[page-1] {
+				if i > 0 {
+					payload += ","
+				}
+				payload += fmt.Sprintf(`{"essential":{"id":"sm%d%d","registeredAt":"%sT00:00:00Z","count":{"comment":10}}}`, page, i, date)
+			}
+		}
+		_, _ = io.WriteString(w, payload+`]}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int{}, requested...)
+	}
+}
+
+func TestGetVideoListNewestFirstStopsSequentialPagingBeforeAfter(t *testing.T) {
+	server, requested := newDatedPageServer(t, 0, [][]string{
+		{"2024-03-01", "2024-02-01"},
+		{"2024-01-20", "2023-12-01"},
+		{"2023-11-01", "2023-10-01"},
+		{"2023-09-01"},
+	})
+	client := NewClient(WithBaseURL(server.URL), WithRetries(1), WithLogger(slog.New(slog.DiscardHandler)), WithSort(SortKeyRegisteredAt, SortOrderDesc))
+
+	got, err := client.GetVideoList(context.Background(), "1", Filter{After: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm10", "sm11", "sm20"}) {
+		t.Fatalf("unexpected ids: %v", VideoIDs(got))
+	}
+	if pages := requested(); !reflect.DeepEqual(pages, []int{1, 2, 3}) {
+		t.Fatalf("expected paging to stop at page 3, requested %v", pages)
+	}
+}
+
+func TestGetVideoListNewestFirstStopsParallelPagingBeforeAfter(t *testing.T) {
+	pages := make([][]string, 10)
+	for i := range pages {
+		pages[i] = []string{"2023-06-01"}
+	}
+	pages[0] = []string{"2024-03-01"}
+	pages[1] = []string{"2024-02-01", "2023-12-01"}
+	server, requested := newDatedPageServer(t, 1000, pages)
+	client := NewClient(WithBaseURL(server.URL), WithRetries(1), WithLogger(slog.New(slog.DiscardHandler)), WithPageConcurrency(2), WithSort(SortKeyRegisteredAt, ""))
+
+	got, err := client.GetVideoList(context.Background(), "1", Filter{After: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm10", "sm20"}) {
+		t.Fatalf("unexpected ids: %v", VideoIDs(got))
+	}
+	for _, page := range requested() {
+		if page > 3+2 {
+			t.Fatalf("expected paging to stop shortly after page 3, requested %v", requested())
+		}
+	}
+}
+
+func TestGetVideoListAscendingOrderDoesNotStopEarly(t *testing.T) {
+	server, requested := newDatedPageServer(t, 0, [][]string{
+		{"2023-01-01"},
+		{"2023-06-01"},
+		{"2024-02-01"},
+	})
+	client := NewClient(WithBaseURL(server.URL), WithRetries(1), WithLogger(slog.New(slog.DiscardHandler)), WithSort(SortKeyRegisteredAt, SortOrderAsc))
+
+	got, err := client.GetVideoList(context.Background(), "1", Filter{After: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm30"}) {
+		t.Fatalf("unexpected ids: %v", VideoIDs(got))
+	}
+	if pages := requested(); !reflect.DeepEqual(pages, []int{1, 2, 3, 4}) {
+		t.Fatalf("expected every page to be requested, got %v", pages)
+	}
+}
+
+func TestGetVideoListDefaultOrderStopsBeforeAfter(t *testing.T) {
+	server, requested := newDatedPageServer(t, 0, [][]string{
+		{"2024-03-01"},
+		{"2023-12-01"},
+		{"2023-11-01"},
+	})
+	client := NewClient(WithBaseURL(server.URL), WithRetries(1), WithLogger(slog.New(slog.DiscardHandler)))
+
+	got, err := client.GetVideoList(context.Background(), "1", Filter{After: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm10"}) {
+		t.Fatalf("unexpected ids: %v", VideoIDs(got))
+	}
+	if pages := requested(); !reflect.DeepEqual(pages, []int{1, 2}) {
+		t.Fatalf("expected paging to stop at page 2, requested %v", pages)
+	}
+}
+
+func TestNewestFirstDefaults(t *testing.T) {
+	tests := []struct {
+		client          *Client
+		defaultNewest   bool
+		wantNewestFirst bool
+	}{
+		{client: NewClient(), defaultNewest: true, wantNewestFirst: true},
+		{client: NewClient(), defaultNewest: false, wantNewestFirst: false},
+		{client: NewClient(WithSort(SortKeyRegisteredAt, "")), defaultNewest: false, wantNewestFirst: true},
+		{client: NewClient(WithSort(SortKeyViewCount, SortOrderDesc)), defaultNewest: true, wantNewestFirst: false},
+	}
+	for i, tt := range tests {
+		if got := tt.client.newestFirst(tt.defaultNewest); got != tt.wantNewestFirst {
+			t.Errorf("case %d: expected newestFirst %v, got %v", i, tt.wantNewestFirst, got)
+		}
+	}
+}
+
+func TestSortQuery(t *testing.T) {
+	if got := NewClient().sortQuery(); got != "" {
+		t.Fatalf("expected no sort parameters by default, got %q", got)
+	}
+	if got := NewClient(WithSort(SortKeyViewCount, SortOrderAsc)).sortQuery(); got != "&sortKey=viewCount&sortOrder=asc" {
+		t.Fatalf("unexpected sort parameters: %q", got)
+	}
+}
@@ -0,0 +1,28 @@
+package niconico
+
+import "time"
+
+// Observer receives request measurements from a Client; its methods are called from concurrent requests.
+type Observer interface {
+	// ObserveRequest is called after each HTTP attempt with its status code, or 0 when no response was received.
+	ObserveRequest(status int)
+	// ObserveRetry is called before each retry with the Retry-After delay of the failed attempt (0 when absent).
+	ObserveRetry(retryAfter time.Duration)
+	// ObserveLimiterWait is called before each attempt with how long the RateLimiter delayed it.
+	ObserveLimiterWait(wait time.Duration)
+	// ObservePage is called after each page or list request with its latency, including retries and cache lookups.
+	ObservePage(latency time.Duration)
+}
+
+// WithObserver sets the Observer of request measurements; nil disables observation.
+func WithObserver(observer Observer) Option {
+	return func(c *Client) { c.observer = observer }
+}
+
+// nopObserver discards measurements.
+type nopObserver struct{}
+
+func (nopObserver) ObserveRequest(int)               {}
+func (nopObserver) ObserveRetry(time.Duration)       {}
+func (nopObserver) ObserveLimiterWait(time.Duration) {}
+func (nopObserver) ObservePage(time.Duration)        {}
@@ -0,0 +1,109 @@
+package niconico
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	mu           sync.Mutex
+	statuses     []int
+	retryAfters  []time.Duration
+	limiterWaits []time.Duration
+	pages        int
+}
+
+func (o *recordingObserver) ObserveRequest(status int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.statuses = append(o.statuses, status)
+}
+
+func (o *recordingObserver) ObserveRetry(retryAfter time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.retryAfters = append(o.retryAfters, retryAfter)
+}
+
+func (o *recordingObserver) ObserveLimiterWait(wait time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.limiterWaits = append(o.limiterWaits, wait)
+}
+
+func (o *recordingObserver) ObservePage(time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pages++
+}
+
+func TestObserverReceivesRequestMeasurements(t *testing.T) {
+	origNow := timeNow
+	origSleep := sleepFn
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	current := base
+	timeNow = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return current
+	}
+	sleepFn = func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		current = current.Add(d)
+		return nil
+	}
+	t.Cleanup(func() {
+		timeNow = origNow
+		sleepFn = origSleep
+	})
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = io.WriteString(w, `{"data":{"items":[]}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	observer := &recordingObserver{}
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithRetries(3),
+		WithTimeout(time.Second),
+		WithRateLimiter(NewRateLimiter(0, time.Second)),
+		WithObserver(observer),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	if _, err := client.GetVideoList(context.Background(), "1", Filter{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK}; !reflect.DeepEqual(observer.statuses, want) {
+		t.Fatalf("unexpected statuses: %v", observer.statuses)
+	}
+	if want := []time.Duration{2 * time.Second, 0}; !reflect.DeepEqual(observer.retryAfters, want) {
+		t.Fatalf("unexpected retry-after delays: %v", observer.retryAfters)
+	}
+	// The 2s Retry-After delay already exceeds the 1s interval; the 200ms backoff after the 503 waits 800ms more for the next slot.
+	if want := []time.Duration{0, 0, 800 * time.Millisecond}; !reflect.DeepEqual(observer.limiterWaits, want) {
+		t.Fatalf("unexpected limiter waits: %v", observer.limiterWaits)
+	}
+	if observer.pages != 1 {
+		t.Fatalf("expected 1 page, got %d", observer.pages)
+	}
+}
@@ -0,0 +1,91 @@
+package niconico
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+type parsedPage struct {
+	Items           []Video
+	Status          int
+	TotalCount      int
+	TotalCountKnown bool
+	NotFound        bool
+}
+
+type parsePageFunc func([]byte) (parsedPage, error)
+
+func (c *Client) fetchPage(ctx context.Context, url string, parsePage parsePageFunc) (parsedPage, error) {
+	body, notFound, err := c.fetchBody(ctx, url)
+	if err != nil {
+		return parsedPage{}, err
+	}
+	if notFound {
+		return parsedPage{NotFound: true}, nil
+	}
+	if body == nil {
+		return parsedPage{}, nil
+	}
+	page, err := parsePage(body)
+	if err != nil {
+		c.logger.Error("failed to unmarshal response body", "error", err)
+		return parsedPage{}, err
+	}
+	if page.Status != http.StatusOK {
+		c.logger.Warn("unexpected meta status", "status", page.Status)
+	}
+	return page, nil
+}
+
+// fetchBody issues a GET request and returns the response body, reporting HTTP 404 as notFound.
+// With a cache, fresh entries are served directly and stale entries are revalidated with their validators.
+func (c *Client) fetchBody(ctx context.Context, url string) ([]byte, bool, error) {
+	startedAt := timeNow()
+	defer func() { c.observer.ObservePage(timeNow().Sub(startedAt)) }()
+	var cached cacheEntry
+	var hit bool
+	var header http.Header
+	if c.cache != nil {
+		cached, hit = c.cache.lookup(url)
+		if hit && c.cache.fresh(cached) {
+			return cached.Body, false, nil
+		}
+		if hit {
+			header = conditionalHeader(cached)
+		}
+	}
+	res, err := c.retriesRequest(ctx, url, header)
+	if err != nil {
+		return nil, false, err
+	}
+	if res == nil {
+		return nil, false, nil
+	}
+	if closeAndIsNotFound(res) {
+		return nil, true, nil
+	}
+	if res.StatusCode == http.StatusNotModified {
+		_ = res.Body.Close()
+		if !hit {
+			return nil, false, errors.New("unexpected status: 304")
+		}
+		if err := c.cache.refresh(cached); err != nil {
+			c.logger.Warn("failed to refresh cache entry", "url", url, "error", err)
+		}
+		return cached.Body, false, nil
+	}
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		c.logger.Error("failed to read response body", "error", err)
+		return nil, false, err
+	}
+	if c.cache != nil {
+		if err := c.cache.store(url, res.Header, body); err != nil {
+			c.logger.Warn("failed to store cache entry", "url", url, "error", err)
+		}
+	}
+	return body, false, nil
+}
@@ -35,8 +35,14 @@ func NewRateLimiter(rateLimit float64, minInterval time.Duration) *RateLimiter {
 
 // Wait blocks until the next request slot is available.
 func (l *RateLimiter) Wait(ctx context.Context, minDelay time.Duration) error {
+	_, err := l.wait(ctx, minDelay)
+	return err
+}
+
+// wait is Wait that also returns how long the limiter delayed the slot beyond minDelay.
+func (l *RateLimiter) wait(ctx context.Context, minDelay time.Duration) (time.Duration, error) {
 	if l == nil {
-		return sleepFn(ctx, minDelay)
+		return 0, sleepFn(ctx, minDelay)
 	}
 	if minDelay < 0 {
 		minDelay = 0
@@ -49,5 +55,5 @@ func (l *RateLimiter) Wait(ctx context.Context, minDelay time.Duration) error {
 	}
 	l.nextTime = readyAt.Add(l.interval)
 	l.mu.Unlock()
-	return sleepFn(ctx, readyAt.Sub(now))
+	return readyAt.Sub(now) - minDelay, sleepFn(ctx, readyAt.Sub(now))
 }
@@ -31,7 +31,7 @@ func closeAndIsNotFound(res *http.Response) bool {
 
 // evaluateResponse validates HTTP responses and returns any retry delay needed.
 func evaluateResponse(res *http.Response) (*http.Response, time.Duration, error) {
-	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNotFound {
+	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusNotModified {
 		return res, 0, nil
 	}
 	retryAfter := retryAfterDelay(res)
@@ -45,26 +45,33 @@ func nextRetryDelay(retryAfter time.Duration, attempt int) time.Duration {
 	return max(retryAfter, wait)
 }
 
-// retriesRequest issues a GET request with retries and rate limiting.
-func retriesRequest(ctx context.Context, url string, httpClientTimeout time.Duration, retries int, limiter *RateLimiter) (*http.Response, error) {
+// retriesRequest issues a GET request with optional extra headers, retries, and rate limiting.
+func (c *Client) retriesRequest(ctx context.Context, url string, header http.Header) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
 	req.Header.Set("X-Frontend-Id", "6")
 	req.Header.Set("Accept", "*/*")
-	client := &http.Client{Timeout: httpClientTimeout}
 
 	var lastErr error
 
 	delay := time.Duration(0)
-	for attempt := 1; attempt <= retries; attempt++ {
-		if err := waitBeforeAttempt(ctx, limiter, delay); err != nil {
+	for attempt := 1; attempt <= c.retries; attempt++ {
+		if err := c.waitBeforeAttempt(ctx, delay); err != nil {
 			return nil, err
 		}
 		delay = 0
 
-		res, err := client.Do(req)
+		res, err := c.httpClient.Do(req)
+		status := 0
+		if res != nil {
+			status = res.StatusCode
+		}
+		c.observer.ObserveRequest(status)
 		if err != nil {
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				if res != nil {
@@ -86,10 +93,11 @@ func retriesRequest(ctx context.Context, url string, httpClientTimeout time.Dura
 			delay = retryAfter
 		}
 
-		if attempt == retries {
+		if attempt == c.retries {
 			return nil, lastErr
 		}
 
+		c.observer.ObserveRetry(delay)
 		delay = nextRetryDelay(delay, attempt)
 	}
 
@@ -112,11 +120,12 @@ func sleepWithContext(ctx context.Context, d time.Duration) error {
 }
 
 // waitBeforeAttempt applies any delay and rate limiting before a request attempt.
-func waitBeforeAttempt(ctx context.Context, limiter *RateLimiter, delay time.Duration) error {
-	if limiter == nil {
-		return sleepFn(ctx, delay)
+func (c *Client) waitBeforeAttempt(ctx context.Context, delay time.Duration) error {
+	limiterWait, err := c.limiter.wait(ctx, delay)
+	if c.limiter != nil {
+		c.observer.ObserveLimiterWait(limiterWait)
 	}
-	return limiter.Wait(ctx, delay)
+	return err
 }
 
 // retryAfterDelay parses Retry-After for 429 responses and returns a delay.
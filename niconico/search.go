@@ -0,0 +1,158 @@
+package niconico
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxSearchOffset is the largest _offset the snapshot search API accepts.
+	maxSearchOffset = 100000
+	searchContext   = "go-nico-list"
+	searchFields    = "contentId,title,description,viewCounter,commentCounter,mylistCounter,likeCounter,lengthSeconds,startTime,thumbnailUrl,userId,channelId"
+)
+
+// ErrSearchFlagsUnavailable is returned for tag and keyword searches whose filter excludes paid or
+// sensitive videos: search results do not report those flags, so no result could be verified.
+var ErrSearchFlagsUnavailable = errors.New("search results do not report payment or sensitive-masking flags")
+
+// GetTagVideoList retrieves the videos tagged exactly with tag, newest first.
+func (c *Client) GetTagVideoList(ctx context.Context, tag string, filter Filter) ([]Video, error) {
+	return c.collectSearchResults(ctx, tag, "tagsExact", filter)
+}
+
+// GetSearchVideoList retrieves the videos whose title, description, or tags match keyword, newest first.
+func (c *Client) GetSearchVideoList(ctx context.Context, keyword string, filter Filter) ([]Video, error) {
+	return c.collectSearchResults(ctx, keyword, "title,description,tags", filter)
+}
+
+// collectSearchResults pages through snapshot search results by offset until an empty page, the reported total, or the API offset limit.
+func (c *Client) collectSearchResults(ctx context.Context, query string, targets string, filter Filter) ([]Video, error) {
+	if filter.ExcludePaid || filter.ExcludeSensitive {
+		return nil, ErrSearchFlagsUnavailable
+	}
+	var videos []Video
+	for offset := 0; offset <= maxSearchOffset; offset += pageSize {
+		parsed, err := c.fetchPage(ctx, c.searchURL(query, targets, filter, offset), parseSearchPage)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil, nil
+			}
+			return videos, err
+		}
+		if parsed.NotFound || len(parsed.Items) == 0 {
+			break
+		}
+		videos = append(videos, filterItems(parsed.Items, filter)...)
+		if parsed.TotalCountKnown && offset+len(parsed.Items) >= parsed.TotalCount {
+			break
+		}
+	}
+	return videos, nil
+}
+
+// searchURL builds a snapshot search request, pushing the filter bounds down to the API.
+func (c *Client) searchURL(query string, targets string, filter Filter, offset int) string {
+	values := url.Values{}
+	values.Set("q", query)
+	values.Set("targets", targets)
+	values.Set("fields", searchFields)
+	values.Set("_sort", "-startTime")
+	values.Set("_offset", strconv.Itoa(offset))
+	values.Set("_limit", strconv.Itoa(pageSize))
+	values.Set("_context", searchContext)
+	values.Set("filters[commentCounter][gt]", strconv.Itoa(filter.CommentCount))
+	setSearchRange(values, "viewCounter", filter.MinViews, filter.MaxViews)
+	setSearchRange(values, "likeCounter", filter.MinLikes, filter.MaxLikes)
+	setSearchRange(values, "mylistCounter", filter.MinMylists, filter.MaxMylists)
+	setSearchRange(values, "commentCounter", filter.MinComments, filter.MaxComments)
+	setSearchRange(values, "lengthSeconds", int(filter.MinDuration.Seconds()), int(filter.MaxDuration.Seconds()))
+	if bound, ok := searchTimeBound(filter.After); ok {
+		values.Set("filters[startTime][gte]", bound)
+	}
+	if bound, ok := searchTimeBound(filter.beforeEnd()); ok {
+		values.Set("filters[startTime][lt]", bound)
+	}
+	if bound, ok := searchTimeBound(filter.Until); ok {
+		values.Set("filters[startTime][lte]", bound)
+	}
+	return c.searchBaseURL + "?" + values.Encode()
+}
+
+// setSearchRange adds inclusive range filters for field, skipping zero (open) bounds.
+func setSearchRange(values url.Values, field string, minimum, maximum int) {
+	if minimum > 0 {
+		values.Set("filters["+field+"][gte]", strconv.Itoa(minimum))
+	}
+	if maximum > 0 {
+		values.Set("filters["+field+"][lte]", strconv.Itoa(maximum))
+	}
+}
+
+// searchTimeBound formats t for a search filter, reporting false for zero or out-of-range times.
+func searchTimeBound(t time.Time) (string, bool) {
+	if t.IsZero() || t.Year() < 1 || t.Year() > 9999 {
+		return "", false
+	}
+	return t.Format(time.RFC3339), true
+}
+
+func parseSearchPage(body []byte) (parsedPage, error) {
+	var payload struct {
+		Meta struct {
+			Status     int  `json:"status"`
+			TotalCount *int `json:"totalCount"`
+		} `json:"meta"`
+		Data []struct {
+			ContentID      string    `json:"contentId"`
+			Title          string    `json:"title"`
+			Description    string    `json:"description"`
+			ViewCounter    int       `json:"viewCounter"`
+			CommentCounter int       `json:"commentCounter"`
+			MylistCounter  int       `json:"mylistCounter"`
+			LikeCounter    int       `json:"likeCounter"`
+			LengthSeconds  int       `json:"lengthSeconds"`
+			StartTime      time.Time `json:"startTime"`
+			ThumbnailURL   string    `json:"thumbnailUrl"`
+			UserID         *int64    `json:"userId"`
+			ChannelID      *int64    `json:"channelId"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return parsedPage{}, err
+	}
+	items := make([]Video, 0, len(payload.Data))
+	for _, it := range payload.Data {
+		video := Video{
+			ID:               it.ContentID,
+			Title:            it.Title,
+			RegisteredAt:     it.StartTime,
+			Count:            VideoCount{View: it.ViewCounter, Comment: it.CommentCounter, Mylist: it.MylistCounter, Like: it.LikeCounter},
+			Duration:         it.LengthSeconds,
+			ShortDescription: it.Description,
+			Thumbnail:        VideoThumbnail{URL: it.ThumbnailURL},
+		}
+		switch {
+		case it.ChannelID != nil:
+			video.Owner = VideoOwner{Type: OwnerTypeChannel, ID: "ch" + strconv.FormatInt(*it.ChannelID, 10)}
+			video.IsChannelVideo = true
+		case it.UserID != nil:
+			video.Owner = VideoOwner{Type: OwnerTypeUser, ID: strconv.FormatInt(*it.UserID, 10)}
+		}
+		items = append(items, video)
+	}
+	totalCount := 0
+	if payload.Meta.TotalCount != nil {
+		totalCount = *payload.Meta.TotalCount
+	}
+	return parsedPage{
+		Items:           items,
+		Status:          payload.Meta.Status,
+		TotalCount:      totalCount,
+		TotalCountKnown: payload.Meta.TotalCount != nil,
+	}, nil
+}
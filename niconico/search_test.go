@@ -0,0 +1,190 @@
+package niconico
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetTagVideoListPagesByOffset(t *testing.T) {
+	var mu sync.Mutex
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("q") != "VOCALOID" || query.Get("targets") != "tagsExact" || query.Get("_sort") != "-startTime" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		mu.Lock()
+		offsets = append(offsets, query.Get("_offset"))
+		mu.Unlock()
+		offset, _ := strconv.Atoi(query.Get("_offset"))
+		w.Header().Set("Content-Type", "application/json")
+		if offset >= 150 {
+			t.Errorf("unexpected request past totalCount: offset=%d", offset)
+			_, _ = io.WriteString(w, `{"meta":{"status":200,"totalCount":150},"data":[]}`)
+			return
+		}
+		count := pageSize
+		if offset == pageSize {
+			count = 50
+		}
+		payload := `{"meta":{"status":200,"totalCount":150},"data":[`
+		for i := range count {
+			if i > 0 {
+				payload += ","
+			}
+			payload += fmt.Sprintf(`{"contentId":"sm%d","startTime":"2024-01-10T00:00:00+09:00","commentCounter":10}`, offset+i)
+		}
+		_, _ = io.WriteString(w, payload+`]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(WithSearchBaseURL(server.URL), WithRetries(1), WithLogger(slog.New(slog.DiscardHandler)))
+	got, err := client.GetTagVideoList(context.Background(), "VOCALOID", Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 150 {
+		t.Fatalf("expected 150 videos, got %d", len(got))
+	}
+	if !reflect.DeepEqual(offsets, []string{"0", "100"}) {
+		t.Fatalf("unexpected offsets: %v", offsets)
+	}
+}
+
+func TestGetSearchVideoListPushesFilterAndMapsMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		want := map[string]string{
+			"q":                           "ゆっくり",
+			"targets":                     "title,description,tags",
+			"filters[commentCounter][gt]": "5",
+			"filters[startTime][gte]":     "2024-01-01T00:00:00Z",
+			"filters[startTime][lt]":      "2024-02-01T00:00:00Z",
+		}
+		for key, value := range want {
+			if got := query.Get(key); got != value {
+				t.Errorf("expected %s=%q, got %q", key, value, got)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"meta":{"status":200,"totalCount":2},"data":[`+
+			`{"contentId":"so2","title":"channel video","startTime":"2024-01-20T00:00:00+09:00","viewCounter":100,"commentCounter":10,"mylistCounter":3,"likeCounter":4,"lengthSeconds":90,"thumbnailUrl":"https://example.com/so2.jpg","channelId":2632720},`+
+			`{"contentId":"sm1","title":"user video","startTime":"2024-01-10T00:00:00+09:00","commentCounter":1,"userId":12345}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(WithSearchBaseURL(server.URL), WithRetries(1), WithLogger(slog.New(slog.DiscardHandler)))
+	got, err := client.GetSearchVideoList(context.Background(), "ゆっくり", Filter{
+		CommentCount: 5,
+		After:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Before:       time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected the client-side filter to drop sm1, got %v", VideoIDs(got))
+	}
+	video := got[0]
+	if video.ID != "so2" || video.Title != "channel video" || video.Duration != 90 || video.Thumbnail.URL != "https://example.com/so2.jpg" {
+		t.Fatalf("unexpected video: %+v", video)
+	}
+	if video.Count != (VideoCount{View: 100, Comment: 10, Mylist: 3, Like: 4}) {
+		t.Fatalf("unexpected counts: %+v", video.Count)
+	}
+	if video.Owner.Type != "channel" || video.Owner.ID != "ch2632720" || !video.IsChannelVideo {
+		t.Fatalf("unexpected owner: %+v", video.Owner)
+	}
+}
+
+func TestSearchURLOmitsOpenDateBounds(t *testing.T) {
+	client := NewClient(WithSearchBaseURL("https://example.com/search"))
+	for _, filter := range []Filter{
+		{},
+		{After: time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC), Before: time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)},
+	} {
+		req, err := http.NewRequest(http.MethodGet, client.searchURL("q", "tagsExact", filter, 0), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		query := req.URL.Query()
+		if query.Has("filters[startTime][gte]") || query.Has("filters[startTime][lt]") {
+			t.Fatalf("expected open date bounds to be omitted, got %s", req.URL.RawQuery)
+		}
+	}
+}
+
+func TestSearchURLPushesRangeBounds(t *testing.T) {
+	client := NewClient(WithSearchBaseURL("https://example.com/search"))
+	filter := Filter{MinViews: 100, MaxLikes: 20, MaxComments: 50, MinDuration: 30 * time.Second, MaxDuration: 10 * time.Minute}
+	req, err := http.NewRequest(http.MethodGet, client.searchURL("q", "tagsExact", filter, 0), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query := req.URL.Query()
+	want := map[string]string{
+		"filters[viewCounter][gte]":    "100",
+		"filters[likeCounter][lte]":    "20",
+		"filters[commentCounter][lte]": "50",
+		"filters[lengthSeconds][gte]":  "30",
+		"filters[lengthSeconds][lte]":  "600",
+	}
+	for key, value := range want {
+		if got := query.Get(key); got != value {
+			t.Errorf("expected %s=%q, got %q", key, value, got)
+		}
+	}
+	if query.Has("filters[viewCounter][lte]") || query.Has("filters[mylistCounter][gte]") {
+		t.Fatalf("expected open bounds to be omitted, got %s", req.URL.RawQuery)
+	}
+}
+
+func TestSearchURLPushesTimezoneDayAndUntil(t *testing.T) {
+	client := NewClient(WithSearchBaseURL("https://example.com/search"))
+	filter := Filter{
+		Before:   time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Until:    time.Date(2024, 4, 30, 12, 0, 0, 0, time.UTC),
+		Location: time.FixedZone("JST", 9*60*60),
+	}
+	req, err := http.NewRequest(http.MethodGet, client.searchURL("q", "tagsExact", filter, 0), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query := req.URL.Query()
+	if got := query.Get("filters[startTime][lt]"); got != "2024-05-02T00:00:00+09:00" {
+		t.Fatalf("unexpected lt bound: %q", got)
+	}
+	if got := query.Get("filters[startTime][lte]"); got != "2024-04-30T12:00:00Z" {
+		t.Fatalf("unexpected lte bound: %q", got)
+	}
+}
+
+func TestSearchRejectsPaidAndSensitiveExclusions(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = io.WriteString(w, `{"meta":{"status":200,"totalCount":1},"data":[{"contentId":"sm1","commentCounter":1}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(WithSearchBaseURL(server.URL), WithLogger(slog.New(slog.DiscardHandler)))
+	for _, filter := range []Filter{{ExcludePaid: true}, {ExcludeSensitive: true}} {
+		got, err := client.GetTagVideoList(context.Background(), "VOCALOID", filter)
+		if !errors.Is(err, ErrSearchFlagsUnavailable) || len(got) != 0 {
+			t.Fatalf("expected ErrSearchFlagsUnavailable, got %v, %v", VideoIDs(got), err)
+		}
+	}
+	if requests != 0 {
+		t.Fatalf("expected no requests, got %d", requests)
+	}
+}
@@ -0,0 +1,84 @@
+package niconico
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// GetSeriesVideoList retrieves the videos of a series ordered by their position in the series.
+func (c *Client) GetSeriesVideoList(ctx context.Context, seriesID string, filter Filter) ([]Video, error) {
+	videos, err := c.collectVideoList(ctx, filter,
+		func(page int) string {
+			return fmt.Sprintf("%s/series/%s?pageSize=%d&page=%d", c.baseURL, seriesID, pageSize, page)
+		},
+		parseSeriesPage,
+		false,
+	)
+	sortBySeriesOrder(videos)
+	return videos, err
+}
+
+func parseSeriesPage(body []byte) (parsedPage, error) {
+	var payload struct {
+		Meta struct {
+			Status int `json:"status"`
+		} `json:"meta"`
+		Data struct {
+			Detail struct {
+				ID    json.Number `json:"id"`
+				Title string      `json:"title"`
+			} `json:"detail"`
+			TotalCount *int `json:"totalCount"`
+			Items      []struct {
+				Meta struct {
+					Order int `json:"order"`
+				} `json:"meta"`
+				Video NicoEssential `json:"video"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return parsedPage{}, err
+	}
+	seriesID, _ := strconv.Atoi(payload.Data.Detail.ID.String())
+	items := make([]Video, 0, len(payload.Data.Items))
+	for _, it := range payload.Data.Items {
+		items = append(items, videoFromEssential(it.Video, NicoSeries{
+			ID:    seriesID,
+			Title: payload.Data.Detail.Title,
+			Order: it.Meta.Order,
+		}))
+	}
+	totalCount := 0
+	if payload.Data.TotalCount != nil {
+		totalCount = *payload.Data.TotalCount
+	}
+	return parsedPage{
+		Items:           items,
+		Status:          payload.Meta.Status,
+		TotalCount:      totalCount,
+		TotalCountKnown: payload.Data.TotalCount != nil,
+	}, nil
+}
+
+// sortBySeriesOrder stably orders videos by series position, keeping videos without a position last.
+func sortBySeriesOrder(videos []Video) {
+	sort.SliceStable(videos, func(i, j int) bool {
+		left, right := seriesOrderOf(videos[i]), seriesOrderOf(videos[j])
+		if left == 0 || right == 0 {
+			return left != 0 && right == 0
+		}
+		return left < right
+	})
+}
+
+// seriesOrderOf returns the series position of a video, or 0 when unknown.
+func seriesOrderOf(video Video) int {
+	if video.Series == nil {
+		return 0
+	}
+	return video.Series.Order
+}
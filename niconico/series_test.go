@@ -0,0 +1,77 @@
+package niconico
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetSeriesVideoListOrdersBySeriesPosition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/series/42") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "1":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"detail":{"id":42,"title":"series-42"},"items":[{"meta":{"order":3},"video":{"id":"sm30","registeredAt":"2024-01-12T00:00:00Z","count":{"comment":10}}},{"meta":{"order":1},"video":{"id":"sm10","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}}}]}}`)
+		case "2":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"detail":{"id":42,"title":"series-42"},"items":[{"meta":{"order":2},"video":{"id":"sm20","registeredAt":"2024-01-11T00:00:00Z","count":{"comment":10}}}]}}`)
+		default:
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[]}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	got, err := newTestClient(server.URL, 1, 1, slog.New(slog.DiscardHandler)).GetSeriesVideoList(context.Background(), "42", Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm10", "sm20", "sm30"}) {
+		t.Fatalf("expected series order, got %v", VideoIDs(got))
+	}
+	for i, video := range got {
+		if video.Series == nil || video.Series.ID != 42 || video.Series.Title != "series-42" || video.Series.Order != i+1 {
+			t.Fatalf("unexpected series metadata for %s: %+v", video.ID, video.Series)
+		}
+	}
+}
+
+func TestGetSeriesVideoListAppliesFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"detail":{"id":1},"items":[{"meta":{"order":1},"video":{"id":"sm1","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":1}}},{"meta":{"order":2},"video":{"id":"sm2","registeredAt":"2024-01-10T00:00:00Z","count":{"comment":10}}}]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	got, err := newTestClient(server.URL, 1, 1, slog.New(slog.DiscardHandler)).GetSeriesVideoList(context.Background(), "1", Filter{CommentCount: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(VideoIDs(got), []string{"sm2"}) {
+		t.Fatalf("unexpected ids: %v", VideoIDs(got))
+	}
+}
+
+func TestSortBySeriesOrderKeepsUnknownPositionsLast(t *testing.T) {
+	videos := []Video{
+		{ID: "sm1"},
+		{ID: "sm2", Series: &VideoSeries{Order: 2}},
+		{ID: "sm3"},
+		{ID: "sm4", Series: &VideoSeries{Order: 1}},
+	}
+	sortBySeriesOrder(videos)
+	if got := VideoIDs(videos); !reflect.DeepEqual(got, []string{"sm4", "sm2", "sm1", "sm3"}) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+}
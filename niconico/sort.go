@@ -25,6 +25,21 @@ func NiconicoSort(slice []string) {
 	})
 }
 
+// SortVideos sorts videos by the numeric part of their IDs in ascending order.
+func SortVideos(videos []Video) {
+	sort.SliceStable(videos, func(i, j int) bool {
+		left := videoIDSortKeyFor(videos[i].ID)
+		right := videoIDSortKeyFor(videos[j].ID)
+		if left.length != right.length {
+			return left.length < right.length
+		}
+		if left.text != right.text {
+			return left.text < right.text
+		}
+		return videos[i].ID < videos[j].ID
+	})
+}
+
 // videoIDSortKeyFor builds an allocation-free key for sorting a video ID.
 func videoIDSortKeyFor(id string) videoIDSortKey {
 	text := videoIDSortText(id)
@@ -75,3 +75,17 @@ func TestNiconicoSortLargeMixedAllocationBudget(t *testing.T) {
 		t.Fatalf("allocation budget exceeded: got %.0f allocs, want <= %d", allocs, allocationBudget)
 	}
 }
+
+func TestSortVideosMatchesNiconicoSort(t *testing.T) {
+	ids := []string{"sm12", "sm3", "xx2", "sm100000000", "sm1"}
+	videos := make([]Video, 0, len(ids))
+	for _, id := range ids {
+		videos = append(videos, Video{ID: id})
+	}
+	SortVideos(videos)
+	want := append([]string(nil), ids...)
+	NiconicoSort(want)
+	if got := VideoIDs(videos); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
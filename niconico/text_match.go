@@ -0,0 +1,203 @@
+package niconico
+
+import (
+	"cmp"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// TextMatchOptions controls how a TextPattern compares video text.
+type TextMatchOptions struct {
+	// IgnoreCase matches letters case-insensitively.
+	IgnoreCase bool
+	// NormalizeWidth folds full-width ASCII to half-width and half-width katakana to full-width before matching.
+	NormalizeWidth bool
+}
+
+// TextPattern is a regular expression matched against video titles or descriptions.
+type TextPattern struct {
+	re             *regexp.Regexp
+	normalizeWidth bool
+}
+
+// CompileTextPattern compiles a Go regular expression for matching video text.
+// With NormalizeWidth, literal characters in the pattern are folded the same way as the text,
+// while operators such as "(" keep their regular expression meaning.
+func CompileTextPattern(pattern string, options TextMatchOptions) (*TextPattern, error) {
+	flags := syntax.Perl
+	if options.IgnoreCase {
+		flags |= syntax.FoldCase
+	}
+	parsed, err := syntax.Parse(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	if options.NormalizeWidth {
+		foldLiteralWidth(parsed)
+	}
+	re, err := regexp.Compile(parsed.String())
+	if err != nil {
+		return nil, err
+	}
+	return &TextPattern{re: re, normalizeWidth: options.NormalizeWidth}, nil
+}
+
+// MatchString reports whether text contains a match of the pattern.
+func (p *TextPattern) MatchString(text string) bool {
+	if p.normalizeWidth {
+		text = FoldWidth(text)
+	}
+	return p.re.MatchString(text)
+}
+
+// String returns the compiled pattern.
+func (p *TextPattern) String() string {
+	return p.re.String()
+}
+
+// foldLiteralWidth applies FoldWidth to the literal runes and character classes of a parsed pattern.
+func foldLiteralWidth(re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		re.Rune = []rune(FoldWidth(string(re.Rune)))
+	case syntax.OpCharClass:
+		re.Rune = foldClassWidth(re.Rune)
+	}
+	for _, sub := range re.Sub {
+		foldLiteralWidth(sub)
+	}
+}
+
+// foldClassWidth rewrites the ranges of a character class to match text folded by FoldWidth.
+// A class usually gains the folded form of its members, so [Ａ-Ｚ] also matches A-Z. The parser stores
+// [^...] and \D as the complement, which reaches unicode.MaxRune; such a class instead drops a folded
+// rune unless every rune folding to it is a member, so [^Ａ] does not match A.
+func foldClassWidth(class []rune) []rune {
+	contains := func(r rune) bool {
+		for i := 0; i+1 < len(class); i += 2 {
+			if r >= class[i] && r <= class[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+	negated := len(class) > 0 && class[len(class)-1] == unicode.MaxRune
+	var added, removed []rune
+	for _, pair := range widthFoldPairs {
+		wide, narrow := pair[0], pair[1]
+		switch {
+		case !negated && contains(wide) && !contains(narrow):
+			added = append(added, narrow)
+		case negated && contains(narrow) && !contains(wide):
+			removed = append(removed, narrow)
+		}
+	}
+	slices.Sort(removed)
+	folded := make([]rune, 0, len(class)+2*len(added))
+	for i := 0; i+1 < len(class); i += 2 {
+		lo, hi := class[i], class[i+1]
+		for _, r := range removed {
+			if r < lo || r > hi {
+				continue
+			}
+			if r > lo {
+				folded = append(folded, lo, r-1)
+			}
+			lo = r + 1
+		}
+		if lo <= hi {
+			folded = append(folded, lo, hi)
+		}
+	}
+	for _, r := range added {
+		folded = append(folded, r, r)
+	}
+	return mergeRanges(folded)
+}
+
+// mergeRanges sorts the lo, hi pairs of a character class and merges those that overlap or touch.
+func mergeRanges(class []rune) []rune {
+	pairs := make([][2]rune, 0, len(class)/2)
+	for i := 0; i+1 < len(class); i += 2 {
+		pairs = append(pairs, [2]rune{class[i], class[i+1]})
+	}
+	slices.SortFunc(pairs, func(a, b [2]rune) int { return cmp.Compare(a[0], b[0]) })
+	merged := make([]rune, 0, len(class))
+	for _, pair := range pairs {
+		if n := len(merged); n > 0 && pair[0] <= merged[n-1]+1 {
+			merged[n-1] = max(merged[n-1], pair[1])
+			continue
+		}
+		merged = append(merged, pair[0], pair[1])
+	}
+	return merged
+}
+
+// widthFoldPairs lists each rune FoldWidth replaces on its own with its replacement.
+var widthFoldPairs = func() [][2]rune {
+	pairs := [][2]rune{{0x3000, ' '}}
+	for r := rune(0xFF01); r <= 0xFF5E; r++ {
+		pairs = append(pairs, [2]rune{r, r - 0xFEE0})
+	}
+	for r := rune(0xFF61); r <= 0xFF9F; r++ {
+		pairs = append(pairs, [2]rune{r, halfWidthKatakana[r-0xFF61]})
+	}
+	return pairs
+}()
+
+// halfWidthKatakana maps U+FF61 through U+FF9F to their full-width forms.
+var halfWidthKatakana = []rune("。「」、・ヲァィゥェォャュョッーアイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワン゛゜")
+
+// FoldWidth folds full-width ASCII and the ideographic space to half-width, and half-width katakana
+// (including a following voiced or semi-voiced sound mark) to full-width.
+func FoldWidth(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r >= 0xFF01 && r <= 0xFF5E:
+			b.WriteRune(r - 0xFEE0)
+		case r == 0x3000:
+			b.WriteRune(' ')
+		case r >= 0xFF61 && r <= 0xFF9F:
+			wide := halfWidthKatakana[r-0xFF61]
+			if i+1 < len(runes) {
+				if voiced, ok := composeSoundMark(wide, runes[i+1]); ok {
+					wide = voiced
+					i++
+				}
+			}
+			b.WriteRune(wide)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// composeSoundMark combines a full-width katakana with a following half-width ﾞ or ﾟ.
+func composeSoundMark(base, mark rune) (rune, bool) {
+	switch mark {
+	case 0xFF9E:
+		switch {
+		case base == 'ウ':
+			return 'ヴ', true
+		case base >= 'カ' && base <= 'チ' && (base-'カ')%2 == 0:
+			return base + 1, true
+		case base >= 'ツ' && base <= 'ト' && (base-'ツ')%2 == 0:
+			return base + 1, true
+		case base >= 'ハ' && base <= 'ホ' && (base-'ハ')%3 == 0:
+			return base + 1, true
+		}
+	case 0xFF9F:
+		if base >= 'ハ' && base <= 'ホ' && (base-'ハ')%3 == 0 {
+			return base + 2, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,93 @@
+package niconico
+
+import (
+	"testing"
+)
+
+func TestFoldWidth(t *testing.T) {
+	tests := map[string]string{
+		"ＭＭＤ　ｄａｎｃｅ１２３！": "MMD dance123!",
+		"ｶﾞﾝﾀﾞﾑ":        "ガンダム",
+		"ﾊﾟﾋﾟﾌﾟﾍﾟﾎﾟ":    "パピプペポ",
+		"ｳﾞｧｲｵﾘﾝ":       "ヴァイオリン",
+		"ﾂﾞﾄﾞｯﾞ":        "ヅドッ゛",
+		"ｱﾞ":            "ア゛",
+		"【生放送】アーカイブ":    "【生放送】アーカイブ",
+	}
+	for input, want := range tests {
+		if got := FoldWidth(input); got != want {
+			t.Fatalf("FoldWidth(%q) = %q, want %q", input, got, want)
+		}
+	}
+	if len(halfWidthKatakana) != 0xFF9F-0xFF61+1 {
+		t.Fatalf("unexpected katakana table length: %d", len(halfWidthKatakana))
+	}
+}
+
+func TestTextPatternOptions(t *testing.T) {
+	tests := []struct {
+		pattern string
+		options TextMatchOptions
+		text    string
+		want    bool
+	}{
+		{pattern: "MMD", text: "mmd dance", want: false},
+		{pattern: "MMD", options: TextMatchOptions{IgnoreCase: true}, text: "mmd dance", want: true},
+		{pattern: "MMD", text: "ＭＭＤ dance", want: false},
+		{pattern: "MMD", options: TextMatchOptions{NormalizeWidth: true}, text: "ＭＭＤ dance", want: true},
+		{pattern: "ＭＭＤ", options: TextMatchOptions{NormalizeWidth: true}, text: "MMD dance", want: true},
+		{pattern: "mmd", options: TextMatchOptions{IgnoreCase: true, NormalizeWidth: true}, text: "ＭＭＤ dance", want: true},
+		{pattern: "^アーカイブ", options: TextMatchOptions{NormalizeWidth: true}, text: "ｱｰｶｲﾌﾞ #3", want: true},
+		{pattern: "（告知|生放送）", options: TextMatchOptions{NormalizeWidth: true}, text: "生放送", want: false},
+		{pattern: "（告知|生放送）", options: TextMatchOptions{NormalizeWidth: true}, text: "(生放送)", want: true},
+		{pattern: "(告知|生放送)", options: TextMatchOptions{NormalizeWidth: true}, text: "【生放送】", want: true},
+		{pattern: "^[Ａ-Ｚ]+$", options: TextMatchOptions{NormalizeWidth: true}, text: "MMD", want: true},
+		{pattern: "^[Ａ-Ｚ]+$", options: TextMatchOptions{NormalizeWidth: true}, text: "ＭＭＤ", want: true},
+		{pattern: "^[ａｂ]+$", options: TextMatchOptions{NormalizeWidth: true}, text: "ａbａ", want: true},
+		{pattern: "^[ａｂ]+$", options: TextMatchOptions{IgnoreCase: true, NormalizeWidth: true}, text: "AB", want: true},
+		{pattern: "[ｱ-ｵ]", options: TextMatchOptions{NormalizeWidth: true}, text: "イ", want: true},
+		{pattern: "[^Ａ]", options: TextMatchOptions{NormalizeWidth: true}, text: "A", want: false},
+		{pattern: "[^Ａ]", options: TextMatchOptions{NormalizeWidth: true}, text: "Ｂ", want: true},
+		{pattern: `\D`, options: TextMatchOptions{NormalizeWidth: true}, text: "１２", want: false},
+		{pattern: "^[a-z]+$", options: TextMatchOptions{NormalizeWidth: true}, text: "ＭＭＤ", want: false},
+	}
+	for _, tt := range tests {
+		pattern, err := CompileTextPattern(tt.pattern, tt.options)
+		if err != nil {
+			t.Fatalf("CompileTextPattern(%q): %v", tt.pattern, err)
+		}
+		if got := pattern.MatchString(tt.text); got != tt.want {
+			t.Fatalf("%q %+v MatchString(%q) = %v, want %v", tt.pattern, tt.options, tt.text, got, tt.want)
+		}
+	}
+	if _, err := CompileTextPattern("(", TextMatchOptions{}); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+}
+
+func TestFilterAppliesTextPatterns(t *testing.T) {
+	options := TextMatchOptions{IgnoreCase: true, NormalizeWidth: true}
+	mustCompile := func(pattern string) *TextPattern {
+		t.Helper()
+		compiled, err := CompileTextPattern(pattern, options)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return compiled
+	}
+	filter := Filter{
+		TitleMatch:         mustCompile("mmd"),
+		TitleExclude:       mustCompile("生放送|告知"),
+		DescriptionExclude: mustCompile("archive"),
+	}
+	items := []Video{
+		{ID: "sm1", Title: "ＭＭＤ dance", Count: VideoCount{Comment: 1}},
+		{ID: "sm2", Title: "MMD 生放送", Count: VideoCount{Comment: 1}},
+		{ID: "sm3", Title: "game", Count: VideoCount{Comment: 1}},
+		{ID: "sm4", Title: "mmd model", ShortDescription: "ARCHIVE of stream", Count: VideoCount{Comment: 1}},
+	}
+	got := VideoIDs(filterItems(items, filter))
+	if len(got) != 1 || got[0] != "sm1" {
+		t.Fatalf("unexpected ids: %v", got)
+	}
+}
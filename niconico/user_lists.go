@@ -0,0 +1,91 @@
+package niconico
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// UserList describes a mylist or series owned by a user.
+type UserList struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	ItemCount int    `json:"item_count"`
+}
+
+// GetUserMylists lists the public mylists owned by a user.
+func (c *Client) GetUserMylists(ctx context.Context, userID string) ([]UserList, error) {
+	body, notFound, err := c.fetchBody(ctx, fmt.Sprintf("%s/users/%s/mylists", c.baseURL, userID))
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if notFound || body == nil {
+		return nil, nil
+	}
+	var payload struct {
+		Data struct {
+			Mylists []struct {
+				ID         json.Number `json:"id"`
+				Name       string      `json:"name"`
+				IsPublic   *bool       `json:"isPublic"`
+				ItemsCount int         `json:"itemsCount"`
+			} `json:"mylists"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		c.logger.Error("failed to unmarshal response body", "error", err)
+		return nil, err
+	}
+	lists := make([]UserList, 0, len(payload.Data.Mylists))
+	for _, mylist := range payload.Data.Mylists {
+		if mylist.IsPublic != nil && !*mylist.IsPublic {
+			continue
+		}
+		lists = append(lists, UserList{ID: mylist.ID.String(), Title: mylist.Name, ItemCount: mylist.ItemsCount})
+	}
+	return lists, nil
+}
+
+// GetUserSeries lists the series owned by a user, following pagination until an empty page.
+func (c *Client) GetUserSeries(ctx context.Context, userID string) ([]UserList, error) {
+	var lists []UserList
+	for page := 1; ; page++ {
+		body, notFound, err := c.fetchBody(ctx, fmt.Sprintf("%s/users/%s/series?pageSize=%d&page=%d", c.baseURL, userID, pageSize, page))
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil, nil
+			}
+			return lists, err
+		}
+		if notFound || body == nil {
+			return lists, nil
+		}
+		var payload struct {
+			Data struct {
+				TotalCount *int `json:"totalCount"`
+				Items      []struct {
+					ID         json.Number `json:"id"`
+					Title      string      `json:"title"`
+					ItemsCount int         `json:"itemsCount"`
+				} `json:"items"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			c.logger.Error("failed to unmarshal response body", "error", err)
+			return lists, err
+		}
+		if len(payload.Data.Items) == 0 {
+			return lists, nil
+		}
+		for _, series := range payload.Data.Items {
+			lists = append(lists, UserList{ID: series.ID.String(), Title: series.Title, ItemCount: series.ItemsCount})
+		}
+		if payload.Data.TotalCount != nil && len(lists) >= *payload.Data.TotalCount {
+			return lists, nil
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package niconico
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestGetUserMylistsSkipsPrivateMylists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/1/mylists" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"mylists":[{"id":10,"name":"public","isPublic":true,"itemsCount":3},{"id":11,"name":"private","isPublic":false},{"id":12,"name":"unknown"}]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	got, err := newTestClient(server.URL, 1, 1, slog.New(slog.DiscardHandler)).GetUserMylists(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []UserList{{ID: "10", Title: "public", ItemCount: 3}, {ID: "12", Title: "unknown"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestGetUserSeriesFollowsPagination(t *testing.T) {
+	var requestedPages []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestedPages = append(requestedPages, r.URL.Query().Get("page"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "1":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"totalCount":2,"items":[{"id":20,"title":"first","itemsCount":5}]}}`)
+		case "2":
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"totalCount":2,"items":[{"id":21,"title":"second","itemsCount":6}]}}`)
+		default:
+			t.Errorf("unexpected page request: %s", r.URL.Query().Get("page"))
+			_, _ = io.WriteString(w, `{"meta":{"status":200},"data":{"items":[]}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	got, err := newTestClient(server.URL, 1, 1, slog.New(slog.DiscardHandler)).GetUserSeries(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []UserList{{ID: "20", Title: "first", ItemCount: 5}, {ID: "21", Title: "second", ItemCount: 6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(requestedPages, []string{"1", "2"}) {
+		t.Fatalf("unexpected requested pages: %v", requestedPages)
+	}
+}
+
+func TestGetUserMylistsReturnsServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	if _, err := newTestClient(server.URL, 1, 1, slog.New(slog.DiscardHandler)).GetUserMylists(context.Background(), "1"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,103 @@
+package niconico
+
+import "time"
+
+// Video holds the metadata of a single video returned by a Client.
+type Video struct {
+	ID                      string         `json:"id"`
+	Title                   string         `json:"title"`
+	RegisteredAt            time.Time      `json:"registered_at"`
+	Count                   VideoCount     `json:"count"`
+	Duration                int            `json:"duration"`
+	ShortDescription        string         `json:"short_description"`
+	Thumbnail               VideoThumbnail `json:"thumbnail"`
+	Owner                   VideoOwner     `json:"owner"`
+	Series                  *VideoSeries   `json:"series,omitempty"`
+	IsChannelVideo          bool           `json:"is_channel_video"`
+	IsPaymentRequired       bool           `json:"is_payment_required"`
+	RequireSensitiveMasking bool           `json:"require_sensitive_masking"`
+}
+
+// VideoCount holds the engagement counters of a video.
+type VideoCount struct {
+	View    int `json:"view"`
+	Comment int `json:"comment"`
+	Mylist  int `json:"mylist"`
+	Like    int `json:"like"`
+}
+
+// VideoThumbnail holds the thumbnail URLs of a video.
+type VideoThumbnail struct {
+	URL        string `json:"url"`
+	MiddleURL  string `json:"middle_url"`
+	LargeURL   string `json:"large_url"`
+	ListingURL string `json:"listing_url"`
+	NHdURL     string `json:"nhd_url"`
+}
+
+// Owner types reported in VideoOwner.Type.
+const (
+	OwnerTypeUser    = "user"
+	OwnerTypeChannel = "channel"
+)
+
+// VideoOwner identifies the user or channel that owns a video.
+type VideoOwner struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	IconURL string `json:"icon_url"`
+}
+
+// VideoSeries identifies the series a video belongs to and its position in it.
+type VideoSeries struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Order int    `json:"order"`
+}
+
+// videoFromEssential converts an API essential payload and optional series into a Video.
+func videoFromEssential(essential NicoEssential, series NicoSeries) Video {
+	video := Video{
+		ID:           essential.ID,
+		Title:        essential.Title,
+		RegisteredAt: essential.RegisteredAt,
+		Count: VideoCount{
+			View:    essential.Count.View,
+			Comment: essential.Count.Comment,
+			Mylist:  essential.Count.Mylist,
+			Like:    essential.Count.Like,
+		},
+		Duration:         essential.Duration,
+		ShortDescription: essential.ShortDescription,
+		Thumbnail: VideoThumbnail{
+			URL:        essential.Thumbnail.URL,
+			MiddleURL:  essential.Thumbnail.MiddleURL,
+			LargeURL:   essential.Thumbnail.LargeURL,
+			ListingURL: essential.Thumbnail.ListingURL,
+			NHdURL:     essential.Thumbnail.NHdURL,
+		},
+		Owner: VideoOwner{
+			Type:    essential.Owner.OwnerType,
+			ID:      essential.Owner.ID,
+			Name:    essential.Owner.Name,
+			IconURL: essential.Owner.IconURL,
+		},
+		IsChannelVideo:          essential.IsChannelVideo,
+		IsPaymentRequired:       essential.IsPaymentRequired,
+		RequireSensitiveMasking: essential.RequireSensitiveMasking,
+	}
+	if series.ID != 0 {
+		video.Series = &VideoSeries{ID: series.ID, Title: series.Title, Order: series.Order}
+	}
+	return video
+}
+
+// VideoIDs returns the IDs of videos in order.
+func VideoIDs(videos []Video) []string {
+	ids := make([]string, 0, len(videos))
+	for _, video := range videos {
+		ids = append(ids, video.ID)
+	}
+	return ids
+}